@@ -0,0 +1,71 @@
+package bitvector
+
+import "math/bits"
+
+// AppendableBitVector is a bit vector that grows by appending bits at the end
+// while answering Rank1 in constant time. Completed superblocks are never
+// touched again, so each append only updates the counts of the last one.
+type AppendableBitVector struct {
+	size  int      // size of the bit vector.
+	ones  int      // the count of 1s in the bit vector.
+	super []int    // the count of 1s before each superblock.
+	block []uint16 // the count of 1s before each word within its superblock.
+	v     []uint64 // the bit vector
+}
+
+// NewAppendableBitVector makes a new empty appendable bit vector.
+func NewAppendableBitVector() *AppendableBitVector {
+	return &AppendableBitVector{}
+}
+
+// Len returns the size of the bit vector.
+func (b AppendableBitVector) Len() int {
+	return b.size
+}
+
+// Append adds a bit of value v at the end of the bit vector.
+func (b *AppendableBitVector) Append(v bool) {
+	k := b.size / bitLength
+	if b.size%bitLength == 0 {
+		if k%superblockWords == 0 {
+			b.super = append(b.super, b.ones)
+		}
+		b.block = append(b.block, uint16(b.ones-b.super[k/superblockWords]))
+		b.v = append(b.v, 0)
+	}
+	if v {
+		b.v[k] |= uint64(1) << uint(b.size%bitLength)
+		b.ones++
+	}
+	b.size++
+}
+
+// Get returns true or false, the value of the i-th bit in the bit vector.
+func (b AppendableBitVector) Get(i int) (bool, error) {
+	if i < 0 || i >= b.size {
+		return false, ErrorOutOfRange
+	}
+	return (b.v[i/bitLength]>>uint(i%bitLength))&1 == 1, nil
+}
+
+// Rank1 returns the count of 1s before the i-th bit.
+func (b AppendableBitVector) Rank1(i int) (int, error) {
+	if i < 0 || i > b.size {
+		return 0, ErrorOutOfRange
+	}
+	k := i / bitLength
+	if k == len(b.v) {
+		return b.ones, nil
+	}
+	offset := uint(i % bitLength)
+	return b.super[k/superblockWords] + int(b.block[k]) + bits.OnesCount64(b.v[k] & ^(maskFF<<offset)), nil
+}
+
+// Rank0 returns the count of 0s before the i-th bit.
+func (b AppendableBitVector) Rank0(i int) (int, error) {
+	val, err := b.Rank1(i)
+	if err != nil {
+		return 0, err
+	}
+	return i - val, nil
+}
@@ -0,0 +1,56 @@
+package bitvector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAppendableBitVector(t *testing.T) {
+	b := NewAppendableBitVector()
+	if got, err := b.Rank1(0); err != nil || got != 0 {
+		t.Errorf("Rank1(0) of an empty vector = %d, %v", got, err)
+	}
+
+	var ref []bool
+	ranks := []int{0}
+	for n := 0; n < 5000; n++ {
+		v := rand.Intn(3) == 0
+		b.Append(v)
+		ref = append(ref, v)
+		ones := ranks[len(ranks)-1]
+		if v {
+			ones++
+		}
+		ranks = append(ranks, ones)
+
+		if b.Len() != len(ref) {
+			t.Fatalf("Len() = %d, want %d", b.Len(), len(ref))
+		}
+		for _, i := range []int{rand.Intn(len(ranks)), len(ranks) - 1, len(ranks) / 2} {
+			if got, err := b.Rank1(i); err != nil || got != ranks[i] {
+				t.Fatalf("Rank1(%d) of size %d = %d, %v, want %d", i, b.Len(), got, err, ranks[i])
+			}
+			if got, _ := b.Rank0(i); got != i-ranks[i] {
+				t.Fatalf("Rank0(%d) of size %d = %d, want %d", i, b.Len(), got, i-ranks[i])
+			}
+		}
+		i := rand.Intn(len(ref))
+		if got, _ := b.Get(i); got != ref[i] {
+			t.Fatalf("Get(%d) = %v, want %v", i, got, ref[i])
+		}
+	}
+
+	if _, err := b.Rank1(b.Len() + 1); err != ErrorOutOfRange {
+		t.Errorf("Rank1(size+1) error = %v, want %v", err, ErrorOutOfRange)
+	}
+	if _, err := b.Get(b.Len()); err != ErrorOutOfRange {
+		t.Errorf("Get(size) error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
+
+func BenchmarkAppend(b *testing.B) {
+	bv := NewAppendableBitVector()
+	for i := 0; i < b.N; i++ {
+		bv.Append(i%3 == 0)
+	}
+}
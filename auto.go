@@ -0,0 +1,30 @@
+package bitvector
+
+// BuildAuto builds the bits of the builder in the representation estimated by
+// EstimateSizes to take the least space: a BitVector, an RRRVector, an SDVector
+// or an RLEVector. A compressed representation is chosen only if it is
+// estimated to take less than half the space of the raw words, since queries on
+// a BitVector are the fastest.
+func (b *Builder) BuildAuto() RankSelect {
+	bv := b.Build()
+	if bv.constant {
+		return bv
+	}
+	sizes := bv.EstimateSizes()
+	best, size := "raw", sizes["raw"]/2
+	for _, scheme := range []string{"rrr", "eliasfano", "runs"} {
+		if sizes[scheme] < size {
+			best, size = scheme, sizes[scheme]
+		}
+	}
+	switch best {
+	case "rrr":
+		return NewRRRVector(bv)
+	case "eliasfano":
+		s, _ := NewSDVector(bv.Positions(), bv.size)
+		return s
+	case "runs":
+		return NewRLEVector(bv)
+	}
+	return bv
+}
@@ -0,0 +1,37 @@
+package bitvector
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBuildAuto(t *testing.T) {
+	const size = 1000000
+	runs, _ := NewFromRuns(randomRuns(size, 10000), size)
+	_, random := random(size)
+	for _, c := range []struct {
+		name string
+		bv   *BitVector
+		want string
+	}{
+		{"random", random, "*bitvector.BitVector"},
+		{"zeros", NewBuilder(size).Build(), "*bitvector.BitVector"},
+		{"sparse", randomDensity(size, 0.001), "*bitvector.SDVector"},
+		{"dense", randomDensity(size, 0.97), "*bitvector.RRRVector"},
+		{"runs", runs, "*bitvector.RLEVector"},
+	} {
+		b := NewBuilder(size)
+		b.SetPositions(c.bv.Positions())
+		got := b.BuildAuto()
+		if typ := fmt.Sprintf("%T", got); typ != c.want {
+			t.Errorf("%s: BuildAuto() = %s, want %s", c.name, typ, c.want)
+		}
+		for i := 0; i <= size; i += 997 {
+			g, _ := got.Rank1(i)
+			w, _ := c.bv.Rank1(i)
+			if g != w {
+				t.Fatalf("%s: Rank1(%d) = %d, want %d", c.name, i, g, w)
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package bitvector
+
+import (
+	"io"
+	"math/bits"
+)
+
+// BitOrder is the numbering of the bits within a byte when a bit vector
+// is converted from or to bytes. The i-th bit is always in the (i/8)-th byte.
+type BitOrder int
+
+const (
+	// LSBFirst numbers the bits of a byte from the least significant bit.
+	// It matches the bits of the words of a BitVector, where the i-th bit is
+	// (v[i/64] >> (i%64)) & 1, and is the order of OrBytes and MarshalDataOnly.
+	LSBFirst BitOrder = iota
+	// MSBFirst numbers the bits of a byte from the most significant bit,
+	// as most network protocols do.
+	MSBFirst
+)
+
+// NewBuilderFromBytes makes a new builder of BitVector of the specified size
+// holding the bits of data in the given order. Missing bits are 0 and bits
+// beyond the size are dropped. Bytes of the builder uses the same order.
+func NewBuilderFromBytes(data []byte, size int, order BitOrder) *Builder {
+	b := NewBuilder(size)
+	b.order = order
+	if order == MSBFirst {
+		reversed := make([]byte, len(data))
+		for i, x := range data {
+			reversed[i] = bits.Reverse8(x)
+		}
+		data = reversed
+	}
+	b.OrBytes(data, 0)
+	return b
+}
+
+// BuildFromReader builds a BitVector of the specified size from the
+// ceil(size/8) bytes read from r in LSBFirst order.
+func BuildFromReader(r io.Reader, size int) (*BitVector, error) {
+	return buildFromReader(r, size, LSBFirst)
+}
+
+// BuildFromReaderBE builds a BitVector of the specified size from the
+// ceil(size/8) bytes read from r in MSBFirst order, as big-endian wire formats pack them.
+func BuildFromReaderBE(r io.Reader, size int) (*BitVector, error) {
+	return buildFromReader(r, size, MSBFirst)
+}
+
+func buildFromReader(r io.Reader, size int, order BitOrder) (*BitVector, error) {
+	if size < 0 {
+		return nil, ErrorOutOfRange
+	}
+	data := make([]byte, (size+7)/8)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return NewBuilderFromBytes(data, size, order).Build(), nil
+}
+
+// Bytes returns the bits packed into ceil(Len()/8) bytes in the given order.
+func (b BitVector) Bytes(order BitOrder) []byte {
+	return packBytes(b.words(), b.size, order)
+}
+
+// packBytes packs the size bits of v into bytes in the given order.
+func packBytes(v []uint64, size int, order BitOrder) []byte {
+	data := make([]byte, (size+7)/8)
+	for i := range data {
+		x := byte(v[i/8] >> uint(i%8*8))
+		if i == len(data)-1 && size%8 != 0 {
+			x &= ^byte(0xff << uint(size%8))
+		}
+		if order == MSBFirst {
+			x = bits.Reverse8(x)
+		}
+		data[i] = x
+	}
+	return data
+}
@@ -0,0 +1,88 @@
+package bitvector
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestBitOrder(t *testing.T) {
+	for _, c := range []struct {
+		positions []int
+		order     BitOrder
+		want      []byte
+	}{
+		{[]int{0, 7}, LSBFirst, []byte{0x81}},
+		{[]int{0, 7}, MSBFirst, []byte{0x81}},
+		{[]int{0, 1}, LSBFirst, []byte{0x03}},
+		{[]int{0, 1}, MSBFirst, []byte{0xc0}},
+		{[]int{0, 9}, LSBFirst, []byte{0x01, 0x02}},
+		{[]int{0, 9}, MSBFirst, []byte{0x80, 0x40}},
+	} {
+		b := NewBuilder(16)
+		for _, i := range c.positions {
+			b.Set1(i)
+		}
+		bv := b.Build()
+		want := append(c.want, make([]byte, 2-len(c.want))...)
+		if got := bv.Bytes(c.order); !slices.Equal(got, want) {
+			t.Errorf("Bytes(%v) with bits %v = %#v, want %#v", c.order, c.positions, got, want)
+		}
+
+		from := NewBuilderFromBytes(want, 16, c.order)
+		if got := from.Bytes(); !slices.Equal(got, want) {
+			t.Errorf("NewBuilderFromBytes(%#v, %v).Bytes() = %#v", want, c.order, got)
+		}
+		if got := from.Build().Positions(); !slices.Equal(got, c.positions) {
+			t.Errorf("NewBuilderFromBytes(%#v, %v) has bits %v, want %v", want, c.order, got, c.positions)
+		}
+	}
+
+	// MarshalDataOnly is pinned to LSBFirst after its 8-byte header.
+	b := NewBuilder(8)
+	b.Set1(0)
+	b.Set1(1)
+	if got := b.Build().MarshalDataOnly()[8:]; !slices.Equal(got, []byte{0x03}) {
+		t.Errorf("MarshalDataOnly() bits = %#v, want 0x03", got)
+	}
+}
+
+func TestNewBuilderFromBytesSize(t *testing.T) {
+	b := NewBuilderFromBytes([]byte{0xff, 0xff}, 12, LSBFirst)
+	if bv := b.Build(); bv.CountOnes() != 12 {
+		t.Errorf("CountOnes() = %d, want 12", bv.CountOnes())
+	}
+	b = NewBuilderFromBytes([]byte{0xff}, 20, MSBFirst)
+	if bv := b.Build(); bv.CountOnes() != 8 || bv.Len() != 20 {
+		t.Errorf("CountOnes(), Len() = %d, %d, want 8, 20", bv.CountOnes(), bv.Len())
+	}
+}
+
+func TestBuildFromReader(t *testing.T) {
+	// testdata/wire.bin holds the bytes 0xc1 0x01 0xf0.
+	data, err := os.ReadFile("testdata/wire.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		build func(io.Reader, int) (*BitVector, error)
+		order BitOrder
+		want  []int
+	}{
+		{BuildFromReaderBE, MSBFirst, []int{0, 1, 7, 15, 16, 17, 18, 19}},
+		{BuildFromReader, LSBFirst, []int{0, 6, 7, 8}},
+	} {
+		bv, err := c.build(bytes.NewReader(data), 20)
+		if err != nil {
+			t.Fatalf("%v: error = %v", c.order, err)
+		}
+		if got := bv.Positions(); bv.Len() != 20 || !slices.Equal(got, c.want) {
+			t.Errorf("%v: Len(), Positions() = %d, %v, want 20, %v", c.order, bv.Len(), got, c.want)
+		}
+		if _, err := c.build(bytes.NewReader(data), 25); err != io.ErrUnexpectedEOF {
+			t.Errorf("%v: short input error = %v, want %v", c.order, err, io.ErrUnexpectedEOF)
+		}
+	}
+}
@@ -2,15 +2,42 @@ package bitvector
 
 import (
 	"errors"
+	"iter"
+	"math"
+	"math/bits"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
 )
 
 const (
 	bitLength = 64
 	maskFF    = uint64(0xffffffffffffffff)
-	mask55    = uint64(0x5555555555555555)
-	mask33    = uint64(0x3333333333333333)
-	mask0F    = uint64(0x0f0f0f0f0f0f0f0f)
-	mask01    = uint64(0x0101010101010101)
+
+	// rank9Bits is the width of a count within a superblock of the rank9 index.
+	rank9Bits = 9
+	rank9Mask = 1<<rank9Bits - 1
+
+	// selectSampleRate is the number of 1s between samples of the select index.
+	selectSampleRate = 512
+	// sparseRatio is the minimum ratio of the size to the count of 1s for which
+	// Build keeps the positions of the 1s to answer Select1 directly.
+	sparseRatio = 256
+	// maxSize is the largest size of a bit vector, so that rounding it up to words cannot overflow int.
+	maxSize = maxInt - bitLength
+
+	// superblockWords is the number of words per superblock of the rank index
+	// kept by Build and AppendableBitVector: an int count of 1s before each
+	// superblock, and a uint16 count before each word within its superblock.
+	// The rank9 index and RMQ share it as their superblock too.
+	superblockWords = 8
+
+	// hintWords is the number of words in a block of the select hints kept by Build.
+	hintWords = 8
+	// groupWords is the number of words, a cache line, covered by a rank entry of BuildSIMDFriendly.
+	// It is a superblock, so that BuildSIMDFriendly keeps the superblock counts of Build.
+	groupWords = superblockWords
 )
 
 var (
@@ -18,12 +45,32 @@ var (
 	ErrorOutOfRange = errors.New("Out of range access")
 	// ErrorNotExist indicates not exist.
 	ErrorNotExist = errors.New("Not exist")
+	// ErrorSizeMismatch indicates the sizes of the operands differ.
+	ErrorSizeMismatch = errors.New("Size mismatch")
+	// ErrorInvalidFormat indicates the data is not in the expected format.
+	ErrorInvalidFormat = errors.New("Invalid format")
+	// ErrorNotSorted indicates the input is not in non-decreasing order.
+	ErrorNotSorted = errors.New("Not sorted")
+	// ErrorNotBuilt indicates the bit vector was not made by a builder or decoder.
+	ErrorNotBuilt = errors.New("Not built")
+	// ErrorNotTree indicates the input does not describe a single tree.
+	ErrorNotTree = errors.New("Not a tree")
 )
 
 type BitVector struct {
-	size int      // size of the bit vector.
-	rank []int    // the vector of the number of 1s in the bit vector pers BitLength.
-	v    []uint64 // the bit vector
+	size    int       // size of the bit vector.
+	rank    []int     // the count of 1s before each superblock, or before each word if block is nil and not grouped.
+	block   []uint16  // the count of 1s before each word within its superblock, or nil.
+	rank9   []uint64  // the rank9 index, in which case rank and block are nil, or nil.
+	store   wordStore // the bit vector
+	select1 []int     // the positions of every selectSampleRate-th 1, or nil without a select index.
+	select0 []int     // the positions of every selectSampleRate-th 0, or nil without a select index over 0s.
+	hints   []int     // the position of the first 1 from each block of hintWords words, or nil.
+	ones    []int     // the positions of the 1s if the bit vector is sparse, or nil.
+
+	grouped  bool   // whether rank has one entry per groupWords words instead of one per word.
+	constant bool   // whether every word is fill, in which case store and rank are nil.
+	fill     uint64 // the word repeated over a constant bit vector.
 }
 
 // Len returns the size of the bit vector.
@@ -31,12 +78,57 @@ func (b BitVector) Len() int {
 	return b.size
 }
 
+// ByteLen returns the number of bytes needed to hold the bits of the bit vector.
+func (b BitVector) ByteLen() int {
+	return (b.size + 7) / 8
+}
+
 // Get returns true or false, the value of the i-th bit in the bit vector.
 func (b BitVector) Get(i int) (bool, error) {
-	if i > b.size {
+	if !b.built() {
+		return false, ErrorNotBuilt
+	}
+	if i < 0 || i >= b.size {
+		return false, ErrorOutOfRange
+	}
+	if b.constant {
+		return b.fill != 0, nil
+	}
+	return ((b.at(i/64) >> uint(i%64)) & 1) == 1, nil
+}
+
+// GetBitReversed returns the bit at i with its low addressBits bits reversed,
+// which is the order of FFT-style algorithms.
+func (b BitVector) GetBitReversed(i, addressBits int) (bool, error) {
+	if i < 0 || addressBits < 0 || addressBits >= bits.UintSize {
 		return false, ErrorOutOfRange
 	}
-	return ((b.v[i/64] >> uint(i%64)) & 1) == 1, nil
+	if addressBits == 0 {
+		return b.Get(i)
+	}
+	low := uint(i) & (1<<uint(addressBits) - 1)
+	j := uint(i) ^ low | bits.Reverse(low)>>uint(bits.UintSize-addressBits)
+	return b.Get(int(j))
+}
+
+// GetBits returns the width bits starting at the i-th bit as an integer,
+// whose lowest bit is the i-th bit. width must be at most 64.
+func (b BitVector) GetBits(i, width int) (uint64, error) {
+	if !b.built() {
+		return 0, ErrorNotBuilt
+	}
+	if i < 0 || width < 0 || width > bitLength || i+width > b.size {
+		return 0, ErrorOutOfRange
+	}
+	if b.constant {
+		return b.fill & ^(maskFF << uint(width)), nil
+	}
+	k, offset := i/bitLength, uint(i%bitLength)
+	x := b.at(k) >> offset
+	if int(offset)+width > bitLength {
+		x |= b.at(k+1) << (bitLength - offset)
+	}
+	return x & ^(maskFF << uint(width)), nil
 }
 
 // Rank returns the count of 1s or 0s before the i-th bit.
@@ -49,11 +141,54 @@ func (b BitVector) Rank(i int, x bool) (int, error) {
 
 // Rank1 returns the count of 1s before the i-th bit.
 func (b BitVector) Rank1(i int) (int, error) {
-	if i > b.size {
+	if !b.built() {
+		return 0, ErrorNotBuilt
+	}
+	if i < 0 || i > b.size {
 		return 0, ErrorOutOfRange
 	}
+	if b.constant && b.fill == 0 {
+		return 0, nil
+	} else if b.constant {
+		return i, nil
+	}
 	offset := uint(i % bitLength)
-	return b.rank[i/bitLength] + popcount(b.v[i/bitLength] & ^(maskFF<<offset)), nil
+	return b.rankWord(i/bitLength) + bits.OnesCount64(b.at(i/bitLength) & ^(maskFF<<offset)), nil
+}
+
+// RankPadded returns the count of 1s before the i-th bit of the bit vector
+// padded with 0s to logicalSize bits, which must be at least Len().
+func (b BitVector) RankPadded(i, logicalSize int) (int, error) {
+	if logicalSize < b.size || i < 0 || i > logicalSize {
+		return 0, ErrorOutOfRange
+	}
+	return b.Rank1(min(i, b.size))
+}
+
+// rankWord returns the count of 1s before the k-th word.
+func (b BitVector) rankWord(k int) int {
+	if s, ok := b.store.(lineStore); ok {
+		return s.rank(k)
+	}
+	if b.rank9 != nil {
+		s, j := k/superblockWords, k%superblockWords
+		rank := int(b.rank9[2*s])
+		if j > 0 {
+			rank += int(b.rank9[2*s+1] >> uint(rank9Bits*(j-1)) & rank9Mask)
+		}
+		return rank
+	}
+	if b.block != nil {
+		return b.rank[k/superblockWords] + int(b.block[k])
+	}
+	if !b.grouped {
+		return b.rank[k]
+	}
+	rank := b.rank[k/groupWords]
+	for j := k &^ (groupWords - 1); j < k; j++ {
+		rank += bits.OnesCount64(b.at(j))
+	}
+	return rank
 }
 
 // Rank0 return the count of 0s before the i-th bit.
@@ -65,6 +200,548 @@ func (b BitVector) Rank0(i int) (int, error) {
 	return i - val, nil
 }
 
+// CountOnes returns the count of 1s in the bit vector.
+func (b BitVector) CountOnes() int {
+	ones, _ := b.Rank1(b.size)
+	return ones
+}
+
+// Entropy returns the zero-order empirical entropy of the bit vector in bits per bit,
+// -p*log2(p) - (1-p)*log2(1-p) where p is the density of 1s.
+// It is 0 for vectors of only 0s or only 1s.
+func (b BitVector) Entropy() float64 {
+	ones := b.CountOnes()
+	if ones == 0 || ones == b.size {
+		return 0
+	}
+	p := float64(ones) / float64(b.size)
+	return -p*math.Log2(p) - (1-p)*math.Log2(1-p)
+}
+
+// CountMatching returns the count of windows of patternBits consecutive bits
+// that equal the lowest patternBits bits of pattern, where the i-th bit of a
+// window corresponds to bit i of pattern. If x is false, it returns the count
+// of windows that differ from pattern instead. patternBits must be in [1, 64].
+func (b BitVector) CountMatching(pattern uint64, patternBits int, x bool) int {
+	if patternBits < 1 || patternBits > bitLength {
+		panic("bitvector: CountMatching pattern length out of range")
+	}
+	windows := b.size - patternBits + 1
+	if windows <= 0 {
+		return 0
+	}
+
+	count := 0
+	for k := 0; k*bitLength < windows; k++ {
+		// Bit t of match is whether the window starting at bit t of the k-th word matches.
+		match := maskFF
+		for j := 0; j < patternBits; j++ {
+			shifted := b.word(k) >> uint(j)
+			if j > 0 {
+				shifted |= b.word(k+1) << uint(bitLength-j)
+			}
+			if (pattern>>uint(j))&1 == 1 {
+				match &= shifted
+			} else {
+				match &^= shifted
+			}
+		}
+		if rest := windows - k*bitLength; rest < bitLength {
+			match &= ^(maskFF << uint(rest))
+		}
+		count += bits.OnesCount64(match)
+	}
+
+	if !x {
+		return windows - count
+	}
+	return count
+}
+
+// EstimateSizes returns the estimated number of bytes to store the bits of the
+// bit vector as "raw" words, "rrr" (class and offset per 63-bit block),
+// "eliasfano" (the positions of the 1s), "gaps-varint" (the gaps between
+// the 1s in uvarint) and "runs" (the start and count of 1s before each run
+// of 1s), computed from the density, gaps and runs without compressing.
+func (b BitVector) EstimateSizes() map[string]int {
+	n, m := b.size, b.CountOnes()
+
+	rrr := (float64(n)*b.Entropy() + float64((n+62)/63*6)) / 8
+
+	eliasFano := 0
+	if m > 0 {
+		lowBits := 0
+		if n > m {
+			lowBits = bits.Len(uint(n/m)) - 1
+		}
+		eliasFano = (m*(lowBits+1) + n>>lowBits + 1 + 7) / 8
+	}
+
+	gaps := 0
+	prev := 0
+	for pos := range b.SetBitsInRange(0, n) {
+		gaps += (bits.Len(uint(pos-prev)|1) + 6) / 7
+		prev = pos
+	}
+
+	return map[string]int{
+		"raw":         b.ByteLen(),
+		"rrr":         int(math.Ceil(rrr)),
+		"eliasfano":   eliasFano,
+		"gaps-varint": gaps,
+		"runs":        16 * b.RunCount1(),
+	}
+}
+
+// Equal returns whether the bit vector has the same size and bits as o.
+func (b BitVector) Equal(o *BitVector) bool {
+	count, err := SymmetricDifferenceCount(&b, o)
+	return err == nil && count == 0
+}
+
+// Positions returns the indices of the 1s in the bit vector in ascending order.
+func (b BitVector) Positions() []int {
+	positions := make([]int, 0, b.CountOnes())
+	for pos := range b.PositionRankPairs() {
+		positions = append(positions, pos)
+	}
+	return positions
+}
+
+// PositionsU32 returns the indices of the 1s in ascending order as uint32s,
+// which take half the memory of Positions. It returns ErrorOutOfRange if
+// the size exceeds math.MaxUint32.
+func (b BitVector) PositionsU32() ([]uint32, error) {
+	if uint64(b.size) > math.MaxUint32 {
+		return nil, ErrorOutOfRange
+	}
+	positions := make([]uint32, 0, b.CountOnes())
+	for pos := range b.PositionRankPairs() {
+		positions = append(positions, uint32(pos))
+	}
+	return positions, nil
+}
+
+// PositionRankPairs returns an iterator over the 1s in the bit vector,
+// yielding the index of each 1 together with its rank (the count of 1s before it).
+func (b BitVector) PositionRankPairs() iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		rank := 0
+		for i := range b.numWords() {
+			for x := b.word(i); x != 0; {
+				pos := i*bitLength + bits.TrailingZeros64(x)
+				if pos >= b.size || !yield(pos, rank) {
+					return
+				}
+				rank++
+				x &= x - 1
+			}
+		}
+	}
+}
+
+// SetBitsInRange returns an iterator over the indices of the 1s in [lo, hi)
+// in ascending order. The range is clamped to [0, Len()).
+func (b BitVector) SetBitsInRange(lo, hi int) iter.Seq[int] {
+	lo, hi = max(lo, 0), min(hi, b.size)
+	return func(yield func(int) bool) {
+		if lo >= hi {
+			return
+		}
+		last := (hi - 1) / bitLength
+		for k := lo / bitLength; k <= last; k++ {
+			x := b.word(k)
+			if k == lo/bitLength {
+				x &= maskFF << uint(lo%bitLength)
+			}
+			if k == last && hi%bitLength != 0 {
+				x &= ^(maskFF << uint(hi%bitLength))
+			}
+			for x != 0 {
+				if !yield(k*bitLength + bits.TrailingZeros64(x)) {
+					return
+				}
+				x &= x - 1
+			}
+		}
+	}
+}
+
+// ParallelForEachSetBit calls f with the index of each 1 in the bit vector,
+// splitting the words into up to workers ranges that are processed concurrently.
+// f must be safe for concurrent calls; the order of the calls is unspecified.
+func (b BitVector) ParallelForEachSetBit(workers int, f func(pos int)) {
+	words := b.numWords()
+	workers = max(1, min(workers, words))
+	chunk := (words + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for start := 0; start < words; start += chunk {
+		end := min(start+chunk, words)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pos := range b.SetBitsInRange(start*bitLength, end*bitLength) {
+				f(pos)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Gaps returns the differences between the indices of consecutive 1s,
+// where the first element is the index of the first 1.
+func (b BitVector) Gaps() []int {
+	gaps := make([]int, 0, b.CountOnes())
+	prev := 0
+	for pos := range b.SetBitsInRange(0, b.size) {
+		gaps = append(gaps, pos-prev)
+		prev = pos
+	}
+	return gaps
+}
+
+// RunCount1 returns the count of maximal runs of consecutive 1s.
+func (b BitVector) RunCount1() int {
+	count := 0
+	var carry uint64 // the last bit of the previous word.
+	for k := range b.numWords() {
+		x := b.word(k)
+		count += bits.OnesCount64(x &^ (x<<1 | carry))
+		carry = x >> (bitLength - 1)
+	}
+	return count
+}
+
+// Transitions returns an iterator over the indices i in [1, Len()) where the
+// i-th bit differs from the (i-1)-th bit, in ascending order.
+func (b BitVector) Transitions() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		var carry uint64 // the last bit of the previous word.
+		for k := range b.numWords() {
+			x := b.word(k)
+			t := x ^ (x<<1 | carry)
+			if k == 0 {
+				t &^= 1
+			}
+			carry = x >> (bitLength - 1)
+			for ; t != 0; t &= t - 1 {
+				pos := k*bitLength + bits.TrailingZeros64(t)
+				if pos >= b.size || !yield(pos) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Sample returns a bit vector of the same size keeping min(k, CountOnes())
+// of the 1s chosen uniformly at random by reservoir sampling.
+func (b BitVector) Sample(k int, rng *rand.Rand) *BitVector {
+	reservoir := make([]int, 0, max(0, min(k, b.CountOnes())))
+	n := 0
+	for pos := range b.SetBitsInRange(0, b.size) {
+		if len(reservoir) < cap(reservoir) {
+			reservoir = append(reservoir, pos)
+		} else if j := rng.Intn(n + 1); j < len(reservoir) {
+			reservoir[j] = pos
+		}
+		n++
+	}
+	s := NewBuilder(b.size)
+	s.SetPositions(reservoir)
+	return s.Build()
+}
+
+// RankOfEachSetBit returns the rank of each 1 in the bit vector, in order.
+// Paired with Positions it gives the bijection between positions and ranks.
+func (b BitVector) RankOfEachSetBit() []int {
+	ranks := make([]int, b.CountOnes())
+	for i := range ranks {
+		ranks[i] = i
+	}
+	return ranks
+}
+
+// RankRange returns the count of 1s in [lo, hi).
+func (b BitVector) RankRange(lo, hi int) (int, error) {
+	if lo < 0 || lo > hi {
+		return 0, ErrorOutOfRange
+	}
+	r, err := b.Rank1(hi)
+	if err != nil {
+		return 0, err
+	}
+	l, _ := b.Rank1(lo)
+	return r - l, nil
+}
+
+// Downsample returns the bit vector of ceil(Len()/factor) bits whose k-th bit
+// is the OR of the bits in [k*factor, (k+1)*factor), or the AND if anyOrAll is true.
+// It panics if factor is not positive.
+func (b BitVector) Downsample(factor int, anyOrAll bool) *BitVector {
+	if factor <= 0 {
+		panic("bitvector: Downsample factor out of range")
+	}
+	d := NewBuilder((b.size + factor - 1) / factor)
+	for k := range d.size {
+		lo, hi := k*factor, min((k+1)*factor, b.size)
+		ones, _ := b.RankRange(lo, hi)
+		if anyOrAll && ones == hi-lo || !anyOrAll && ones > 0 {
+			d.Set1(k)
+		}
+	}
+	return d.Build()
+}
+
+// RankParity returns the parity (XOR) of the bits before the i-th bit, as 0 or 1.
+func (b BitVector) RankParity(i int) (int, error) {
+	r, err := b.Rank1(i)
+	return r & 1, err
+}
+
+// Parity returns whether the XOR of the bits in [lo, hi) is 1.
+func (b BitVector) Parity(lo, hi int) (bool, error) {
+	r, err := b.RankRange(lo, hi)
+	return r&1 == 1, err
+}
+
+// NavigateDown returns Rank1(i) and true if the i-th bit is 1, or Rank0(i)
+// and false otherwise, which is the step down a wavelet tree or LOUDS.
+func (b BitVector) NavigateDown(i int) (child int, isRight bool, err error) {
+	if !b.built() {
+		return 0, false, ErrorNotBuilt
+	}
+	if i < 0 || i >= b.size {
+		return 0, false, ErrorOutOfRange
+	}
+	if b.constant {
+		return i, b.fill != 0, nil
+	}
+	x, offset := b.at(i/bitLength), uint(i%bitLength)
+	ones := b.rankWord(i/bitLength) + bits.OnesCount64(x & ^(maskFF<<offset))
+	if (x>>offset)&1 == 1 {
+		return ones, true, nil
+	}
+	return i - ones, false, nil
+}
+
+// FindClose returns the index of the 0 matching the 1 at openPos, where the bit
+// vector encodes balanced parentheses with 1 as '(' and 0 as ')'. It returns
+// ErrorNotExist if the bit at openPos is not 1 or has no match.
+func (b BitVector) FindClose(openPos int) (int, error) {
+	if open, err := b.Get(openPos); err != nil {
+		return 0, err
+	} else if !open {
+		return 0, ErrorNotExist
+	}
+	excess := 0
+	for i := openPos; i < b.size; i++ {
+		if b.word(i/bitLength)>>uint(i%bitLength)&1 == 1 {
+			excess++
+		} else if excess--; excess == 0 {
+			return i, nil
+		}
+	}
+	return 0, ErrorNotExist
+}
+
+// RankBoth returns the count of 1s and the count of 0s before the i-th bit.
+func (b BitVector) RankBoth(i int) (ones, zeros int, err error) {
+	ones, err = b.Rank1(i)
+	if err != nil {
+		return 0, 0, err
+	}
+	return ones, i - ones, nil
+}
+
+// wordStore is the storage of the words of a bit vector, so that the rank
+// and select code does not depend on where the words are kept.
+type wordStore interface {
+	Word(k int) uint64 // returns the k-th word.
+	Len() int          // returns the number of words.
+}
+
+// sliceStore is a wordStore of words in memory.
+type sliceStore []uint64
+
+func (s sliceStore) Word(k int) uint64 {
+	return s[k]
+}
+
+func (s sliceStore) Len() int {
+	return len(s)
+}
+
+// at returns the k-th stored word, without the dynamic call for the stores of this package.
+func (b BitVector) at(k int) uint64 {
+	switch s := b.store.(type) {
+	case sliceStore:
+		return s[k]
+	case lineStore:
+		return s.v[s.index(k)]
+	}
+	return b.store.Word(k)
+}
+
+// built reports whether the bit vector has its words, unlike the zero value.
+func (b BitVector) built() bool {
+	return b.store != nil || b.constant
+}
+
+// numWords returns the number of words of the bit vector.
+func (b BitVector) numWords() int {
+	return b.size/bitLength + 1
+}
+
+// word returns the k-th word of the bit vector with the bits beyond the size
+// cleared, or 0 if k is beyond the last word or the bit vector is the zero value.
+func (b BitVector) word(k int) uint64 {
+	if k >= b.numWords() {
+		return 0
+	}
+	x := b.fill
+	if !b.constant && b.store != nil {
+		x = b.at(k)
+	}
+	if k == b.size/bitLength {
+		return x & ^(maskFF << uint(b.size%bitLength))
+	}
+	return x
+}
+
+// words returns the words of the bit vector, which are made unless they are in a sliceStore.
+func (b BitVector) words() []uint64 {
+	if s, ok := b.store.(sliceStore); ok {
+		return s
+	}
+	v := make([]uint64, b.numWords())
+	for k := range v {
+		v[k] = b.word(k)
+	}
+	return v
+}
+
+// SizeInBytes returns the number of bytes used by the bits and the indexes of the bit vector.
+func (b BitVector) SizeInBytes() int {
+	words := 0
+	if s, ok := b.store.(lineStore); ok {
+		words = len(s.v)
+	} else if b.store != nil {
+		words = b.store.Len()
+	}
+	return 8*(words+len(b.rank)+len(b.select1)+len(b.select0)+len(b.ones)+len(b.hints)+len(b.rank9)) + 2*len(b.block)
+}
+
+// SanitizeTail clears the bits beyond the size in the last word and recomputes
+// the ranks from the words, repairing a bit vector decoded from an untrusted source.
+// It must not be called on a bit vector returned by OpenFile, which is read-only,
+// and does nothing unless the words are kept in memory.
+func (b *BitVector) SanitizeTail() {
+	if _, ok := b.store.(lineStore); ok {
+		b.store = newLineStore(b.words())
+		return
+	}
+	v, ok := b.store.(sliceStore)
+	if !ok {
+		return
+	}
+	v[b.size/bitLength] &= ^(maskFF << uint(b.size%bitLength))
+	if b.rank9 != nil {
+		copy(b.rank9, rank9Index(v))
+		return
+	}
+	rank := 0
+	for k, x := range v {
+		switch {
+		case b.block == nil && !b.grouped:
+			b.rank[k] = rank
+		case k%superblockWords == 0:
+			b.rank[k/superblockWords] = rank
+		}
+		if b.block != nil {
+			b.block[k] = uint16(rank - b.rank[k/superblockWords])
+		}
+		rank += bits.OnesCount64(x)
+	}
+}
+
+// WithWordReplaced returns a copy of the bit vector whose wordIndex-th word of
+// 64 bits is word, recomputing only the ranks after it. Bits beyond the size are
+// dropped. The copy has no select index or select hints, so Select1 and Select0
+// search over the ranks. It panics if wordIndex is out of range.
+func (b BitVector) WithWordReplaced(wordIndex int, word uint64) *BitVector {
+	if wordIndex < 0 || wordIndex >= b.numWords() {
+		panic("bitvector: word index out of range")
+	}
+	if wordIndex == b.size/bitLength {
+		word &= ^(maskFF << uint(b.size%bitLength))
+	}
+	c := b
+	c.select1, c.select0, c.hints = nil, nil, nil
+	v := slices.Clone(b.words())
+	delta := bits.OnesCount64(word) - bits.OnesCount64(b.word(wordIndex))
+	v[wordIndex] = word
+	c.store = sliceStore(v)
+
+	if b.constant || !b.built() {
+		c.constant, c.fill, c.grouped = false, 0, false
+		c.rank, c.block = superblockRanks(v)
+	} else if b.rank9 != nil {
+		c.rank9 = rank9Index(v)
+	} else if _, ok := b.store.(lineStore); ok {
+		c.store = newLineStore(v)
+	} else {
+		c.rank = slices.Clone(b.rank)
+		first := wordIndex + 1
+		if b.block != nil || b.grouped {
+			first = wordIndex/superblockWords + 1
+		}
+		for k := first; k < len(c.rank); k++ {
+			c.rank[k] += delta
+		}
+		if b.block != nil {
+			c.block = slices.Clone(b.block)
+			for k := wordIndex + 1; k < min(first*superblockWords, len(c.block)); k++ {
+				c.block[k] = uint16(int(c.block[k]) + delta)
+			}
+		}
+	}
+	if b.ones != nil {
+		c.ones = nil
+		c.ones = c.Positions()
+	}
+	return &c
+}
+
+// BlockBits returns the number of bits in a block of the rank index.
+func (b BitVector) BlockBits() int {
+	return bitLength
+}
+
+// BlockRanks returns a copy of the rank index, whose k-th entry is Rank1(k * BlockBits()).
+func (b BitVector) BlockRanks() []int {
+	if !b.constant && !b.grouped && b.block == nil && b.rank9 == nil && b.rank != nil {
+		return slices.Clone(b.rank)
+	}
+	ranks := make([]int, b.numWords())
+	for k := range ranks {
+		ranks[k], _ = b.Rank1(k * bitLength)
+	}
+	return ranks
+}
+
+// BlockHistogram returns the count of 1s within each block of the rank index,
+// the last of which is the partial block at the end.
+func (b BitVector) BlockHistogram() []int {
+	hist := b.BlockRanks()
+	for k := range len(hist) - 1 {
+		hist[k] = hist[k+1] - hist[k]
+	}
+	hist[len(hist)-1] = b.CountOnes() - hist[len(hist)-1]
+	return hist
+}
+
 func (b BitVector) Select(i int, x bool) (int, error) {
 	if x {
 		return b.Select1(i)
@@ -72,60 +749,163 @@ func (b BitVector) Select(i int, x bool) (int, error) {
 	return b.Select0(i)
 }
 
-// Select1 returns the index of the i-th 1.
+// Select1 returns the index of the i-th 1. It returns ErrorOutOfRange for
+// a negative i and ErrorNotExist if there are not more than i 1s.
+// Sparse bit vectors answer from the positions of the 1s kept by Build,
+// and others by a binary search over the ranks, narrowed by the select index
+// or else by the select hints of Build.
 func (b BitVector) Select1(i int) (int, error) {
-	return b.binarySearch(i, true)
+	if !b.built() {
+		return 0, ErrorNotBuilt
+	}
+	if i < 0 {
+		return 0, ErrorOutOfRange
+	}
+	if i >= b.CountOnes() {
+		return 0, ErrorNotExist
+	}
+	if b.constant {
+		return i, nil
+	}
+	if b.ones != nil {
+		return b.ones[i], nil
+	}
+
+	low, high := 0, b.size+1
+	if k := i / selectSampleRate; k < len(b.select1) {
+		low = b.select1[k]
+		if k+1 < len(b.select1) {
+			high = b.select1[k+1]
+		}
+	} else if b.hints != nil {
+		// The i-th 1 is in the last block starting with at most i 1s.
+		k := sort.Search(len(b.hints), func(k int) bool { return b.rankWord(k*hintWords) > i }) - 1
+		low, high = b.hints[k], min((k+1)*hintWords*bitLength, b.size)+1
+	}
+	return b.selectWords(i, true, low, high)
 }
 
-// Select0 returns the index of the i-th 0.
-func (b BitVector) Select0(i int) (int, error) {
-	return b.binarySearch(i, false)
+// Select1FromEnd returns the index of the k-th 1 counting from the last 1.
+func (b BitVector) Select1FromEnd(k int) (int, error) {
+	ones := b.CountOnes()
+	if k < 0 || k >= ones {
+		return 0, ErrorNotExist
+	}
+	return b.Select1(ones - 1 - k)
 }
 
-func (b BitVector) binarySearch(t int, x bool) (int, error) {
-	if x {
-		v, _ := b.Rank1(b.size)
-		if t > v {
-			return t, ErrorNotExist
-		}
-	} else {
-		v, _ := b.Rank0(b.size)
-		if t > v {
-			return t, ErrorNotExist
-		}
+// Select0 returns the index of the i-th 0. It returns ErrorOutOfRange for
+// a negative i and ErrorNotExist if there are not more than i 0s.
+func (b BitVector) Select0(i int) (int, error) {
+	if !b.built() {
+		return 0, ErrorNotBuilt
+	}
+	if i < 0 {
+		return 0, ErrorOutOfRange
+	}
+	if i >= b.size-b.CountOnes() {
+		return 0, ErrorNotExist
+	}
+	if b.constant {
+		return i, nil
 	}
 
 	low, high := 0, b.size+1
-	for high-low > 1 {
-		mid := (high + low) / 2
+	if k := i / selectSampleRate; k < len(b.select0) {
+		low = b.select0[k]
+		if k+1 < len(b.select0) {
+			high = b.select0[k+1]
+		}
+	}
+	return b.selectWords(i, false, low, high)
+}
+
+// HasSelectIndex returns whether the bit vector was built with a select index,
+// which speeds up Select1 or Select0.
+func (b BitVector) HasSelectIndex() bool {
+	return b.select1 != nil || b.select0 != nil
+}
+
+// Slice returns a copy of the bits in [start, end) as a new bit vector.
+func (b BitVector) Slice(start, end int) (*BitVector, error) {
+	if start < 0 || end > b.size || start > end {
+		return nil, ErrorOutOfRange
+	}
+	s := NewBuilder(end - start)
+	for i := start; i < end; i += bitLength {
+		width := min(bitLength, end-i)
+		x, _ := b.GetBits(i, width)
+		setBits(s.v, i-start, uint(width), x)
+	}
+	return s.Build(), nil
+}
 
+// selectWords returns the index of the t-th 1 (or 0 if x is false),
+// which must exist and lie in [low, high). It searches the rank index for
+// the word holding it and then selects within that word, and returns
+// ErrorInvalidFormat if the indexes do not agree with the words.
+func (b BitVector) selectWords(t int, x bool, low, high int) (int, error) {
+	before := func(k int) int {
 		if x {
-			v, _ := b.Rank1(mid)
-			if v > t {
-				high = mid
-			} else {
-				low = mid
-			}
-		} else {
-			v, _ := b.Rank0(mid)
-			if v > t {
-				high = mid
-			} else {
-				low = mid
-			}
+			return b.rankWord(k)
 		}
+		return k*bitLength - b.rankWord(k)
 	}
-	return high - 1, nil
+	lo, hi := low/bitLength, (high-1)/bitLength
+	k := lo + sort.Search(hi-lo+1, func(j int) bool { return before(lo+j) > t }) - 1
+	if k < 0 || k >= b.numWords() {
+		return 0, ErrorInvalidFormat
+	}
+	w := b.word(k)
+	if !x {
+		w = ^w
+	}
+	pos, err := selectInWord(w, t-before(k))
+	if err != nil {
+		return 0, err
+	}
+	return k*bitLength + pos, nil
+}
+
+// selectInWord returns the position of the r-th 1 in x, or ErrorInvalidFormat
+// if x does not have more than r 1s.
+func selectInWord(x uint64, r int) (int, error) {
+	if r < 0 || r >= bits.OnesCount64(x) {
+		return 0, ErrorInvalidFormat
+	}
+	shift := 0
+	for c := bits.OnesCount8(uint8(x)); r >= c; c = bits.OnesCount8(uint8(x >> shift)) {
+		r -= c
+		shift += 8
+	}
+	x >>= uint(shift)
+	for ; r > 0; r-- {
+		x &= x - 1
+	}
+	return shift + bits.TrailingZeros64(x), nil
 }
 
 // Builder is a builder of BitVector.
+//
+// A builder is single-use: the built BitVector shares the builder's bits,
+// so modifying the builder or building again after Build panics until
+// Reset or LoadFrom gives the builder new bits.
 type Builder struct {
-	size int
-	v    []uint64
+	size    int
+	v       []uint64
+	order   BitOrder // the numbering of the bits within each byte for Bytes.
+	built   bool     // whether Build has been called.
+	density float64  // the expected fraction of 1s, or 0 without a hint.
+	lenient bool     // whether Set ignores indices out of range.
+	selects bool     // whether Build samples select indexes over both 1s and 0s.
 }
 
 // NewBuilder makes a new builder of BitVector of the specified size.
+// It panics if size is negative or too large to index with an int.
 func NewBuilder(size int) *Builder {
+	if size < 0 || size > maxSize {
+		panic("bitvector: Builder size out of range")
+	}
 	bufsize := size/64 + 1
 
 	return &Builder{
@@ -134,13 +914,59 @@ func NewBuilder(size int) *Builder {
 	}
 }
 
+// NewBuilderWithHint makes a new builder of BitVector of the specified size
+// whose fraction of 1s is expected to be about expectedDensity. Build uses the
+// hint to collect the positions of a sparse bit vector while counting the 1s,
+// but still chooses the representation from the actual count.
+func NewBuilderWithHint(size int, expectedDensity float64) *Builder {
+	b := NewBuilder(size)
+	b.density = expectedDensity
+	return b
+}
+
+// NewBuilderLenient makes a new builder of BitVector of the specified size
+// whose Set, Set1 and Set0 silently ignore an index out of [0, size),
+// so that noisy positions can be fed without filtering them first.
+func NewBuilderLenient(size int) *Builder {
+	b := NewBuilder(size)
+	b.lenient = true
+	return b
+}
+
+// NewBuilderWithSelectIndex makes a new builder of BitVector of the specified size
+// whose Build also samples the positions of every selectSampleRate-th 1 and 0,
+// so that Select1 and Select0 take near-constant time at the cost of extra space.
+func NewBuilderWithSelectIndex(size int) *Builder {
+	b := NewBuilder(size)
+	b.selects = true
+	return b
+}
+
+// NewBuilderMSBFirst makes a new builder of BitVector of the specified size
+// whose Bytes uses MSBFirst order, as most network protocols do.
+// Queries on the built BitVector are unaffected.
+func NewBuilderMSBFirst(size int) *Builder {
+	b := NewBuilder(size)
+	b.order = MSBFirst
+	return b
+}
+
 // Len returns the size of the bit vector.
 func (b Builder) Len() int {
 	return b.size
 }
 
+// ByteLen returns the number of bytes needed to hold the bits of the bit vector.
+func (b Builder) ByteLen() int {
+	return (b.size + 7) / 8
+}
+
 // Set sets i-th bit in the bit vector to v.
 func (b *Builder) Set(i int, v bool) {
+	b.checkNotBuilt()
+	if b.lenient && (i < 0 || i >= b.size) {
+		return
+	}
 	if v {
 		b.v[i/64] |= uint64(1) << uint(i%64)
 	} else {
@@ -148,6 +974,88 @@ func (b *Builder) Set(i int, v bool) {
 	}
 }
 
+// SetPositions sets the bits at positions to 1. Positions within the same word
+// are set together, so sorted input is set with one write per word.
+// It panics on a position out of [0, size), which a lenient builder ignores.
+func (b *Builder) SetPositions(positions []int) {
+	b.checkNotBuilt()
+	for i := 0; i < len(positions); {
+		if pos := positions[i]; pos < 0 || pos >= b.size {
+			if !b.lenient {
+				panic("bitvector: SetPositions position out of range")
+			}
+			i++
+			continue
+		}
+		k := positions[i] / bitLength
+		end := min((k+1)*bitLength, b.size)
+		var x uint64
+		for ; i < len(positions) && positions[i] >= k*bitLength && positions[i] < end; i++ {
+			x |= uint64(1) << uint(positions[i]%bitLength)
+		}
+		b.v[k] |= x
+	}
+}
+
+// SetAll sets every bit in the bit vector to 1.
+func (b *Builder) SetAll() {
+	b.checkNotBuilt()
+	for i := range b.v {
+		b.v[i] = maskFF
+	}
+	b.clearTail()
+}
+
+// SetRange sets the bits in [lo, hi) to 1.
+func (b *Builder) SetRange(lo, hi int) {
+	b.applyRange(lo, hi, func(x, mask uint64) uint64 { return x | mask })
+}
+
+// FlipRange inverts the bits in [i, j). It does nothing if i == j.
+func (b *Builder) FlipRange(i, j int) {
+	b.applyRange(i, j, func(x, mask uint64) uint64 { return x ^ mask })
+}
+
+// ToggleRange inverts the bits in [i, j), the same as FlipRange.
+func (b *Builder) ToggleRange(i, j int) {
+	b.FlipRange(i, j)
+}
+
+// applyRange replaces each word overlapping [lo, hi) with op of the word
+// and the mask of its bits in the range.
+func (b *Builder) applyRange(lo, hi int, op func(x, mask uint64) uint64) {
+	b.checkNotBuilt()
+	for i := lo; i < hi; {
+		k, offset := i/bitLength, uint(i%bitLength)
+		mask := maskFF << offset
+		if n := hi - k*bitLength; n < bitLength {
+			mask &= ^(maskFF << uint(n))
+		}
+		b.v[k] = op(b.v[k], mask)
+		i = (k + 1) * bitLength
+	}
+}
+
+// NewFromRuns builds a BitVector of the specified size whose 1s are the runs
+// given as [start, length] pairs. Overlapping runs are merged.
+func NewFromRuns(runs [][2]int, size int) (*BitVector, error) {
+	b := NewBuilder(size)
+	for _, run := range runs {
+		start, length := run[0], run[1]
+		if start < 0 || length < 0 || start > size-length {
+			return nil, ErrorOutOfRange
+		}
+		b.SetRange(start, start+length)
+	}
+	return b.Build(), nil
+}
+
+// ClearAll sets every bit in the bit vector to 0.
+func (b *Builder) ClearAll() {
+	b.checkNotBuilt()
+	clear(b.v)
+}
+
 // Set1 sets i-th bit in the bit vector to 1.
 func (b *Builder) Set1(i int) {
 	b.Set(i, true)
@@ -158,31 +1066,377 @@ func (b *Builder) Set0(i int) {
 	b.Set(i, false)
 }
 
+// TestAndSet sets i-th bit in the bit vector to 1 and returns its previous value.
+func (b *Builder) TestAndSet(i int) (was bool) {
+	b.checkNotBuilt()
+	mask := uint64(1) << uint(i%64)
+	was = b.v[i/64]&mask != 0
+	b.v[i/64] |= mask
+	return was
+}
+
 // Get returns true or false, i-th bit in the bit vector.
 func (b Builder) Get(i int) bool {
-	return (b.v[i/64] << uint(i%64) & 1) == 1
+	return (b.v[i/64]>>uint(i%64))&1 == 1
 }
 
-// Build builds a BitVector from the builder.
-func (b Builder) Build() *BitVector {
-	rank := make([]int, len(b.v))
+// PopcountWordRange returns the count of 1s in the words [startWord, endWord)
+// of the builder, including any bits beyond the size in the last word.
+func (b Builder) PopcountWordRange(startWord, endWord int) int {
 	count := 0
+	for _, x := range b.v[startWord:endWord] {
+		count += bits.OnesCount64(x)
+	}
+	return count
+}
+
+// Bytes returns the bits packed into ceil(Len()/8) bytes, where the i-th bit
+// is in the (i/8)-th byte. Bits are numbered within a byte in LSBFirst order,
+// or MSBFirst for NewBuilderMSBFirst and NewBuilderFromBytes with MSBFirst.
+func (b Builder) Bytes() []byte {
+	return packBytes(b.v, b.size, b.order)
+}
+
+// GetBits returns the width bits starting at the i-th bit as an integer,
+// whose lowest bit is the i-th bit. width must be at most 64.
+func (b Builder) GetBits(i, width int) uint64 {
+	return getBits(b.v, i, uint(width))
+}
+
+// SetBits sets the width bits starting at the i-th bit to the lowest width bits of x,
+// whose lowest bit goes to the i-th bit. width must be at most 64.
+func (b *Builder) SetBits(i, width int, x uint64) {
+	b.checkNotBuilt()
+	setBits(b.v, i, uint(width), x)
+}
+
+// OrBytes sets the bits of the bit vector from bitOffset on to the bitwise OR
+// of themselves and the bits of data in LSBFirst order. Bits beyond the size are dropped.
+func (b *Builder) OrBytes(data []byte, bitOffset int) {
+	b.checkNotBuilt()
+	for j, x := range data {
+		pos := bitOffset + j*8
+		if pos >= b.size {
+			break
+		}
+		k, offset := pos/bitLength, uint(pos%bitLength)
+		b.v[k] |= uint64(x) << offset
+		if offset > bitLength-8 && k+1 < len(b.v) {
+			b.v[k+1] |= uint64(x) >> (bitLength - offset)
+		}
+	}
+	b.clearTail()
+}
+
+// ShiftLeft moves every bit of the bit vector n positions towards the end,
+// i.e. bit i becomes bit i+n. Bits shifted past the size are dropped and the
+// first n bits are filled with 0s. A negative n shifts towards the start.
+func (b *Builder) ShiftLeft(n int) {
+	b.checkNotBuilt()
+	// Shifting by the size or more clears every bit, even where -n overflows.
+	if n <= -b.size || n >= b.size {
+		clear(b.v)
+		return
+	}
+	if n < 0 {
+		b.ShiftRight(-n)
+		return
+	}
+	words, offset := n/bitLength, uint(n%bitLength)
+	for k := len(b.v) - 1; k >= 0; k-- {
+		var w uint64
+		if src := k - words; src >= 0 {
+			w = b.v[src] << offset
+			if offset > 0 && src > 0 {
+				w |= b.v[src-1] >> (bitLength - offset)
+			}
+		}
+		b.v[k] = w
+	}
+	b.clearTail()
+}
+
+// ShiftRight moves every bit of the bit vector n positions towards the start,
+// i.e. bit i becomes bit i-n. Bits shifted before the start are dropped and the
+// last n bits are filled with 0s. A negative n shifts towards the end.
+func (b *Builder) ShiftRight(n int) {
+	b.checkNotBuilt()
+	if n <= -b.size || n >= b.size {
+		clear(b.v)
+		return
+	}
+	if n < 0 {
+		b.ShiftLeft(-n)
+		return
+	}
+	b.clearTail()
+	words, offset := n/bitLength, uint(n%bitLength)
+	for k := range b.v {
+		var w uint64
+		if src := k + words; src < len(b.v) {
+			w = b.v[src] >> offset
+			if offset > 0 && src+1 < len(b.v) {
+				w |= b.v[src+1] << (bitLength - offset)
+			}
+		}
+		b.v[k] = w
+	}
+}
 
+// LoadFrom replaces the contents of the builder with a copy of the bits of v,
+// after which the builder can be used again even if Build has been called.
+func (b *Builder) LoadFrom(v *BitVector) {
+	b.size = v.size
+	b.v = make([]uint64, v.numWords())
+	copy(b.v, v.words())
+	b.built = false
+}
+
+// TrimToSize reduces the size of the bit vector to newSize,
+// dropping the bits at and beyond newSize.
+func (b *Builder) TrimToSize(newSize int) error {
+	b.checkNotBuilt()
+	if newSize < 0 || newSize > b.size {
+		return ErrorOutOfRange
+	}
+	b.size = newSize
+	b.v = b.v[:newSize/bitLength+1]
+	b.clearTail()
+	return nil
+}
+
+// XorWith flips the bits of the bit vector wherever mask has a 1.
+func (b *Builder) XorWith(mask *Builder) error {
+	b.checkNotBuilt()
+	if b.size != mask.size {
+		return ErrorSizeMismatch
+	}
+	for i, x := range mask.v {
+		b.v[i] ^= x
+	}
+	return nil
+}
+
+// clearTail sets the bits at and beyond the size to 0.
+func (b *Builder) clearTail() {
+	k := b.size / bitLength
+	b.v[k] &= ^(maskFF << uint(b.size%bitLength))
+	for k++; k < len(b.v); k++ {
+		b.v[k] = 0
+	}
+}
+
+// Reset clears the builder to all 0s with new bits, so that it can be used again after Build.
+func (b *Builder) Reset() {
+	b.v = make([]uint64, len(b.v))
+	b.built = false
+}
+
+// checkNotBuilt panics if Build has been called on the builder.
+func (b *Builder) checkNotBuilt() {
+	if b.built {
+		panic("bitvector: Builder used after Build; call Reset to reuse it")
+	}
+}
+
+// Build builds a BitVector from the builder.
+// A bit vector of only 0s or only 1s is built without storing any words.
+// Build marks the builder as built, so it takes a pointer: a Builder value
+// that is not addressable, such as a map element, cannot call it.
+func (b *Builder) Build() *BitVector {
+	b.checkNotBuilt()
+	b.built = true
+	rank, block := superblockRanks(b.v)
+
+	var positions []int
+	if b.density > 0 && b.density*sparseRatio <= 1 {
+		positions = make([]int, 0, int(b.density*float64(b.size))+1)
+	}
 	for i, x := range b.v {
-		rank[i] = count
-		count += popcount(x)
+		for ; positions != nil && x != 0; x &= x - 1 {
+			if pos := i*bitLength + bits.TrailingZeros64(x); pos < b.size {
+				positions = append(positions, pos)
+			}
+		}
+		if len(positions)*sparseRatio > b.size {
+			positions = nil // the hint was wrong.
+		}
 	}
 
-	return &BitVector{
-		size: b.size,
-		v:    b.v,
-		rank: rank,
+	bv := &BitVector{
+		size:  b.size,
+		store: sliceStore(b.v),
+		rank:  rank,
+		block: block,
 	}
+	switch ones := bv.CountOnes(); {
+	case ones == 0 || ones == b.size:
+		bv.store, bv.rank, bv.block, bv.constant = nil, nil, nil, true
+		if ones > 0 {
+			bv.fill = maskFF
+		}
+	case ones*sparseRatio <= b.size && positions != nil:
+		bv.ones = positions
+	case ones*sparseRatio <= b.size:
+		bv.ones = bv.Positions()
+	default:
+		bv.hints = make([]int, (len(b.v)+hintWords-1)/hintWords)
+		next := b.size
+		for k := len(b.v) - 1; k >= 0; k-- {
+			if x := bv.word(k); x != 0 {
+				next = k*bitLength + bits.TrailingZeros64(x)
+			}
+			if k%hintWords == 0 {
+				bv.hints[k/hintWords] = next
+			}
+		}
+	}
+	if b.selects && !bv.constant {
+		if bv.ones == nil {
+			bv.select1 = bv.sampleSelect1()
+		}
+		bv.select0 = bv.sampleSelect0()
+	}
+	return bv
+}
+
+// superblockRanks returns the count of 1s in v before each superblock and
+// the count before each word within its superblock.
+func superblockRanks(v []uint64) ([]int, []uint16) {
+	rank := make([]int, (len(v)+superblockWords-1)/superblockWords)
+	block := make([]uint16, len(v))
+	count := 0
+	for k, x := range v {
+		if k%superblockWords == 0 {
+			rank[k/superblockWords] = count
+		}
+		block[k] = uint16(count - rank[k/superblockWords])
+		count += bits.OnesCount64(x)
+	}
+	return rank, block
+}
+
+// BuildWithSelectIndex builds a BitVector from the builder together with
+// a select index that narrows the search of Select1 if selectTarget is true,
+// or of Select0 otherwise, as for an allocator looking for free slots.
+func (b *Builder) BuildWithSelectIndex(selectTarget bool) *BitVector {
+	bv := b.Build()
+	if selectTarget {
+		bv.select1 = bv.sampleSelect1()
+	} else {
+		bv.select0 = bv.sampleSelect0()
+	}
+	return bv
+}
+
+// sampleSelect1 returns the position of every selectSampleRate-th 1.
+func (b BitVector) sampleSelect1() []int {
+	samples := make([]int, 0, b.CountOnes()/selectSampleRate+1)
+	for pos, rank := range b.PositionRankPairs() {
+		if rank%selectSampleRate == 0 {
+			samples = append(samples, pos)
+		}
+	}
+	return samples
 }
 
-func popcount(x uint64) int {
-	x = (x & mask55) + (x >> 1 & mask55)
-	x = (x & mask33) + (x >> 2 & mask33)
-	x = (x + (x >> 4)) & mask0F
-	return int(x * mask01 >> 56 & uint64(0x7f))
+// sampleSelect0 returns the position of every selectSampleRate-th 0.
+func (b BitVector) sampleSelect0() []int {
+	samples := make([]int, 0, (b.size-b.CountOnes())/selectSampleRate+1)
+	zeros := 0
+	for k := range b.numWords() {
+		x := ^b.word(k)
+		if k == b.size/bitLength {
+			x &= ^(maskFF << uint(b.size%bitLength))
+		}
+		for ; x != 0; x &= x - 1 {
+			if zeros%selectSampleRate == 0 {
+				samples = append(samples, k*bitLength+bits.TrailingZeros64(x))
+			}
+			zeros++
+		}
+	}
+	return samples
+}
+
+// BuildAndMarshal builds a BitVector from the builder and returns it together with its binary form.
+func (b *Builder) BuildAndMarshal() (*BitVector, []byte, error) {
+	bv := b.Build()
+	data, err := bv.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	return bv, data, nil
+}
+
+// BuildSIMDFriendly builds a BitVector from the builder with a rank entry per
+// groupWords words and no counts within them. Rank1 then sums the popcounts of
+// the words of a single cache line, while the smaller index causes fewer cache misses.
+func (b *Builder) BuildSIMDFriendly() *BitVector {
+	bv := b.Build()
+	if bv.constant {
+		return bv
+	}
+	bv.block, bv.grouped = nil, true
+	return bv
+}
+
+// BuildRank9 builds a BitVector from the builder with the rank9 index, which
+// keeps the count of 1s before each superblock next to a word packing the
+// rank9Bits-bit counts before its other words. Rank1 then reads a single
+// pair of words of the index, which takes 25% of the space of the bits
+// rather than the 37.5% of the default layout.
+func (b *Builder) BuildRank9() *BitVector {
+	bv := b.Build()
+	if bv.constant {
+		return bv
+	}
+	bv.rank9 = rank9Index(b.v)
+	bv.rank, bv.block = nil, nil
+	return bv
+}
+
+// rank9Index returns the rank9 index of v: for each superblock, the count of 1s
+// before it, then the counts before its words 1 to superblockWords-1 within it.
+func rank9Index(v []uint64) []uint64 {
+	index := make([]uint64, 2*((len(v)+superblockWords-1)/superblockWords))
+	count := 0
+	for k, x := range v {
+		s, j := k/superblockWords, k%superblockWords
+		if j == 0 {
+			index[2*s] = uint64(count)
+		} else {
+			index[2*s+1] |= uint64(count-int(index[2*s])) << uint(rank9Bits*(j-1))
+		}
+		count += bits.OnesCount64(x)
+	}
+	return index
+}
+
+// getBits returns the width bits of v starting at the i-th bit.
+func getBits(v []uint64, i int, width uint) uint64 {
+	if width == 0 {
+		return 0
+	}
+	k, offset := i/bitLength, uint(i%bitLength)
+	x := v[k] >> offset
+	if offset+width > bitLength {
+		x |= v[k+1] << (bitLength - offset)
+	}
+	return x & ^(maskFF << width)
+}
+
+// setBits overwrites the width bits of v starting at the i-th bit with x.
+func setBits(v []uint64, i int, width uint, x uint64) {
+	if width == 0 {
+		return
+	}
+	mask := ^(maskFF << width)
+	x &= mask
+	k, offset := i/bitLength, uint(i%bitLength)
+	v[k] = v[k]&^(mask<<offset) | x<<offset
+	if offset+width > bitLength {
+		shift := bitLength - offset
+		v[k+1] = v[k+1]&^(mask>>shift) | x>>shift
+	}
 }
@@ -1,6 +1,8 @@
 package bitvector
 
 import (
+	"fmt"
+	"math/bits"
 	"math/rand"
 	"testing"
 )
@@ -36,6 +38,189 @@ func BenchmarkGet(b *testing.B) {
 	b.StopTimer()
 }
 
+func BenchmarkSelect1Density(b *testing.B) {
+	for _, density := range []float64{0.001, 0.1, 0.9} {
+		bv := randomDensity(bigSize, density)
+		ones := bv.CountOnes()
+		search := *bv
+		search.ones = nil
+		positions := *bv
+		positions.ones = bv.Positions()
+
+		for _, c := range []struct {
+			name string
+			bv   *BitVector
+		}{{"auto", bv}, {"search", &search}, {"positions", &positions}} {
+			b.Run(fmt.Sprintf("%g/%s", density, c.name), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					c.bv.Select1(rand.Intn(ones))
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkSetPositions(b *testing.B) {
+	positions := clustered(bigSize)
+	bv := NewBuilder(bigSize)
+	b.Run("SetPositions", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bv.SetPositions(positions)
+		}
+	})
+	b.Run("Set1", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, pos := range positions {
+				bv.Set1(pos)
+			}
+		}
+	})
+}
+
+func BenchmarkRank1Layout(b *testing.B) {
+	const size = 1e7
+	s, _ := random(size)
+	builder := func() *Builder {
+		bv := NewBuilder(size)
+		for i, c := range s {
+			bv.Set(i, c == '1')
+		}
+		return bv
+	}
+	for _, c := range []struct {
+		name string
+		bv   *BitVector
+	}{{"default", builder().Build()}, {"SIMDFriendly", builder().BuildSIMDFriendly()}, {"rank9", builder().BuildRank9()}, {"interleaved", builder().BuildInterleaved()}} {
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				c.bv.Rank1(rand.Intn(size))
+			}
+		})
+	}
+}
+
+func BenchmarkBuildWithHint(b *testing.B) {
+	const density = 0.001
+	positions := randomDensity(bigSize, density).Positions()
+	for _, c := range []struct {
+		name    string
+		builder func() *Builder
+	}{
+		{"none", func() *Builder { return NewBuilder(bigSize) }},
+		{"hint", func() *Builder { return NewBuilderWithHint(bigSize, density) }},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				bv := c.builder()
+				bv.SetPositions(positions)
+				b.StartTimer()
+				bv.Build()
+			}
+		})
+	}
+}
+
+func BenchmarkSelect0Index(b *testing.B) {
+	s, _ := random(bigSize)
+	for _, selectTarget := range []bool{true, false} {
+		builder := NewBuilder(bigSize)
+		for i, c := range s {
+			builder.Set(i, c == '1')
+		}
+		bv := builder.BuildWithSelectIndex(selectTarget)
+		zeros := bv.Len() - bv.CountOnes()
+		b.Run(fmt.Sprintf("selectTarget=%v", selectTarget), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bv.Select0(rand.Intn(zeros))
+			}
+		})
+	}
+}
+
+func BenchmarkSelectHints(b *testing.B) {
+	_, hinted := random(bigSize)
+	unhinted := *hinted
+	unhinted.hints = nil
+	ones := hinted.CountOnes()
+	for _, c := range []struct {
+		name string
+		bv   *BitVector
+	}{{"hinted", hinted}, {"unhinted", &unhinted}} {
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				c.bv.Select1(rand.Intn(ones))
+			}
+		})
+	}
+}
+
+func BenchmarkSelectIndex(b *testing.B) {
+	s, _ := random(bigSize)
+	for _, c := range []struct {
+		name    string
+		builder *Builder
+	}{{"plain", NewBuilder(bigSize)}, {"indexed", NewBuilderWithSelectIndex(bigSize)}} {
+		for i, x := range s {
+			c.builder.Set(i, x == '1')
+		}
+		bv := c.builder.Build()
+		ones := bv.CountOnes()
+		b.Run(c.name+"/Select1", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bv.Select1(rand.Intn(ones))
+			}
+		})
+		b.Run(c.name+"/Select0", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bv.Select0(rand.Intn(bigSize - ones))
+			}
+		})
+	}
+}
+
+// swarPopcount is the software popcount that bits.OnesCount64 replaced, kept to compare against.
+func swarPopcount(x uint64) int {
+	x = (x & 0x5555555555555555) + (x >> 1 & 0x5555555555555555)
+	x = (x & 0x3333333333333333) + (x >> 2 & 0x3333333333333333)
+	x = (x + (x >> 4)) & 0x0f0f0f0f0f0f0f0f
+	return int(x * 0x0101010101010101 >> 56)
+}
+
+var popcountSink int
+
+func BenchmarkPopcount(b *testing.B) {
+	v := make([]uint64, bigSize/bitLength)
+	for k := range v {
+		v[k] = rand.Uint64()
+	}
+	for _, c := range []struct {
+		name  string
+		count func(uint64) int
+	}{{"swar", swarPopcount}, {"OnesCount64", bits.OnesCount64}} {
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				n := 0
+				for _, x := range v {
+					n += c.count(x)
+				}
+				popcountSink = n
+			}
+		})
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	_, bv := random(bigSize)
+	builder := NewBuilder(bigSize)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		builder.LoadFrom(bv)
+		b.StartTimer()
+		builder.Build()
+	}
+}
+
 func itoB(i int) bool {
 	return i != 0
 }
@@ -54,3 +239,25 @@ func random(size int) (string, *BitVector) {
 
 	return string(bs), b.Build()
 }
+
+func randomDensity(size int, density float64) *BitVector {
+	b := NewBuilder(size)
+	for i := 0; i < size; i++ {
+		if rand.Float64() < density {
+			b.Set1(i)
+		}
+	}
+	return b.Build()
+}
+
+// clustered returns sorted positions below size in runs of consecutive positions.
+func clustered(size int) []int {
+	var positions []int
+	for i := 0; i < size; i += rand.Intn(1000) {
+		for n := rand.Intn(200); n > 0 && i < size; n-- {
+			positions = append(positions, i)
+			i++
+		}
+	}
+	return positions
+}
@@ -0,0 +1,1482 @@
+package bitvector
+
+import (
+	"math"
+	"math/bits"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func positionsOf(s string) []int {
+	var positions []int
+	for i, c := range s {
+		if c == '1' {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+func TestPositionRankPairs(t *testing.T) {
+	s, bv := random(1000)
+
+	positions := bv.Positions()
+	if want := positionsOf(s); !slices.Equal(positions, want) {
+		t.Fatalf("Positions() = %v, want %v", positions, want)
+	}
+
+	ranks := bv.RankOfEachSetBit()
+	if len(ranks) != len(positions) {
+		t.Fatalf("len(RankOfEachSetBit()) = %d, want %d", len(ranks), len(positions))
+	}
+	n := 0
+	for pos, rank := range bv.PositionRankPairs() {
+		if pos != positions[n] || rank != n || ranks[n] != n {
+			t.Errorf("pair %d = (%d, %d), want (%d, %d)", n, pos, rank, positions[n], n)
+		}
+		n++
+	}
+	if n != len(positions) {
+		t.Errorf("PositionRankPairs() yielded %d pairs, want %d", n, len(positions))
+	}
+}
+
+func TestShift(t *testing.T) {
+	const size = 300
+	for _, n := range []int{0, 1, 5, 63, 64, 65, 130, 299, 300, 1000} {
+		s, _ := random(size)
+
+		left, right := NewBuilder(size), NewBuilder(size)
+		for i, c := range s {
+			if c == '1' {
+				left.Set1(i)
+				right.Set1(i)
+			}
+		}
+		left.ShiftLeft(n)
+		right.ShiftRight(n)
+
+		for i := 0; i < size; i++ {
+			want := i-n >= 0 && s[i-n] == '1'
+			if got := left.Get(i); got != want {
+				t.Errorf("ShiftLeft(%d): bit %d = %v, want %v", n, i, got, want)
+			}
+			want = i+n < size && s[i+n] == '1'
+			if got := right.Get(i); got != want {
+				t.Errorf("ShiftRight(%d): bit %d = %v, want %v", n, i, got, want)
+			}
+		}
+		if ones, _ := left.Build().Rank1(size); ones != len(positionsOf(s[:max(size-n, 0)])) {
+			t.Errorf("ShiftLeft(%d): Rank1(size) = %d", n, ones)
+		}
+	}
+
+	// Shifts by at least the size clear every bit, including those whose
+	// negation overflows.
+	for _, n := range []int{math.MinInt, -size, size, math.MaxInt} {
+		left, right := NewBuilder(size), NewBuilder(size)
+		left.SetAll()
+		right.SetAll()
+		left.ShiftLeft(n)
+		right.ShiftRight(n)
+		if ones := left.PopcountWordRange(0, len(left.v)); ones != 0 {
+			t.Errorf("ShiftLeft(%d) left %d 1s", n, ones)
+		}
+		if ones := right.PopcountWordRange(0, len(right.v)); ones != 0 {
+			t.Errorf("ShiftRight(%d) left %d 1s", n, ones)
+		}
+	}
+}
+
+func TestRankBoth(t *testing.T) {
+	s, bv := random(1000)
+	for i := 0; i <= len(s); i += 7 {
+		ones, zeros, err := bv.RankBoth(i)
+		if err != nil {
+			t.Fatalf("RankBoth(%d): %v", i, err)
+		}
+		if ones+zeros != i {
+			t.Errorf("RankBoth(%d) = (%d, %d), sum %d", i, ones, zeros, ones+zeros)
+		}
+		if want := len(positionsOf(s[:i])); ones != want {
+			t.Errorf("RankBoth(%d) ones = %d, want %d", i, ones, want)
+		}
+	}
+	if _, _, err := bv.RankBoth(len(s) + 1); err != ErrorOutOfRange {
+		t.Errorf("RankBoth(size+1) error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
+
+func TestXorWith(t *testing.T) {
+	const size = 200
+	s, _ := random(size)
+	m, _ := random(size)
+	b, mask := NewBuilder(size), NewBuilder(size)
+	for i := 0; i < size; i++ {
+		b.Set(i, s[i] == '1')
+		mask.Set(i, m[i] == '1')
+	}
+
+	if err := b.XorWith(mask); err != nil {
+		t.Fatalf("XorWith(): %v", err)
+	}
+	for i := 0; i < size; i++ {
+		if want := s[i] != m[i]; b.Get(i) != want {
+			t.Errorf("bit %d = %v, want %v", i, b.Get(i), want)
+		}
+	}
+	b.XorWith(mask)
+	for i := 0; i < size; i++ {
+		if want := s[i] == '1'; b.Get(i) != want {
+			t.Errorf("bit %d after applying the mask twice = %v, want %v", i, b.Get(i), want)
+		}
+	}
+
+	if err := b.XorWith(NewBuilder(size + 1)); err != ErrorSizeMismatch {
+		t.Errorf("XorWith(larger) error = %v, want %v", err, ErrorSizeMismatch)
+	}
+}
+
+func TestTrimToSize(t *testing.T) {
+	const size = 1000
+	s, _ := random(size)
+	for _, n := range []int{size, 999, 640, 129, 64, 1, 0} {
+		b := NewBuilder(size)
+		for i := 0; i < size; i++ {
+			b.Set(i, s[i] == '1')
+		}
+		if err := b.TrimToSize(n); err != nil {
+			t.Fatalf("TrimToSize(%d): %v", n, err)
+		}
+		bv := b.Build()
+		if bv.Len() != n {
+			t.Errorf("TrimToSize(%d): Len() = %d", n, bv.Len())
+		}
+		if want := len(positionsOf(s[:n])); bv.CountOnes() != want {
+			t.Errorf("TrimToSize(%d): CountOnes() = %d, want %d", n, bv.CountOnes(), want)
+		}
+		if !slices.Equal(bv.Positions(), positionsOf(s[:n])) {
+			t.Errorf("TrimToSize(%d): Positions() kept bits beyond the new size", n)
+		}
+	}
+
+	if err := NewBuilder(10).TrimToSize(11); err != ErrorOutOfRange {
+		t.Errorf("TrimToSize(larger) error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
+
+func TestLoadFrom(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 1000} {
+		_, bv := random(size)
+		b := NewBuilder(1)
+		b.LoadFrom(bv)
+		if got := b.Build(); !got.Equal(bv) {
+			t.Errorf("LoadFrom(v).Build() of size %d differs from v", size)
+		}
+
+		// Mutating the builder must not change v.
+		if size > 0 {
+			want, _ := bv.Get(0)
+			b.LoadFrom(bv)
+			b.Set(0, !want)
+			if got, _ := bv.Get(0); got != want {
+				t.Errorf("Set() after LoadFrom(v) changed v")
+			}
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	_, a := random(100)
+	b := NewBuilder(100)
+	b.LoadFrom(a)
+	if !a.Equal(b.Build()) {
+		t.Errorf("Equal() of a copy = false")
+	}
+	b.LoadFrom(a)
+	v, _ := a.Get(50)
+	b.Set(50, !v)
+	if a.Equal(b.Build()) {
+		t.Errorf("Equal() with a flipped bit = true")
+	}
+	if a.Equal(NewBuilder(101).Build()) {
+		t.Errorf("Equal() of a different size = true")
+	}
+}
+
+func TestParity(t *testing.T) {
+	s, bv := random(300)
+	parity := make([]int, len(s)+1)
+	for i, c := range s {
+		parity[i+1] = parity[i]
+		if c == '1' {
+			parity[i+1] ^= 1
+		}
+	}
+
+	for i := range parity {
+		if got, err := bv.RankParity(i); err != nil || got != parity[i] {
+			t.Errorf("RankParity(%d) = %d, %v, want %d", i, got, err, parity[i])
+		}
+	}
+	for lo := 0; lo <= len(s); lo += 13 {
+		for hi := lo; hi <= len(s); hi += 7 {
+			got, err := bv.Parity(lo, hi)
+			if want := parity[lo] != parity[hi]; err != nil || got != want {
+				t.Errorf("Parity(%d, %d) = %v, %v, want %v", lo, hi, got, err, want)
+			}
+			count, _ := bv.RankRange(lo, hi)
+			if want := len(positionsOf(s[lo:hi])); count != want {
+				t.Errorf("RankRange(%d, %d) = %d, want %d", lo, hi, count, want)
+			}
+		}
+	}
+
+	if _, err := bv.Parity(5, 4); err != ErrorOutOfRange {
+		t.Errorf("Parity(5, 4) error = %v, want %v", err, ErrorOutOfRange)
+	}
+	if _, err := bv.RankRange(0, len(s)+1); err != ErrorOutOfRange {
+		t.Errorf("RankRange(0, size+1) error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
+
+func TestSetBitsInRange(t *testing.T) {
+	_, bv := random(500)
+	positions := bv.Positions()
+	for _, r := range [][2]int{{0, 500}, {0, 0}, {10, 10}, {1, 63}, {63, 65}, {64, 128}, {100, 450}, {-5, 1000}, {300, 200}} {
+		lo, hi := r[0], r[1]
+		var want []int
+		for _, pos := range positions {
+			if lo <= pos && pos < hi {
+				want = append(want, pos)
+			}
+		}
+		if got := slices.Collect(bv.SetBitsInRange(lo, hi)); !slices.Equal(got, want) {
+			t.Errorf("SetBitsInRange(%d, %d) = %v, want %v", lo, hi, got, want)
+		}
+	}
+}
+
+func TestSelectIndex(t *testing.T) {
+	const size = 20000
+	s, plain := random(size)
+	b := NewBuilder(size)
+	for i, c := range s {
+		b.Set(i, c == '1')
+	}
+	indexed := b.BuildWithSelectIndex(true)
+
+	if plain.HasSelectIndex() {
+		t.Errorf("HasSelectIndex() = true after Build")
+	}
+	if !indexed.HasSelectIndex() {
+		t.Errorf("HasSelectIndex() = false after BuildWithSelectIndex")
+	}
+	if !NewBuilder(0).BuildWithSelectIndex(true).HasSelectIndex() {
+		t.Errorf("HasSelectIndex() = false for an empty vector built with BuildWithSelectIndex")
+	}
+
+	for i, pos := range positionsOf(s) {
+		if got, err := indexed.Select1(i); err != nil || got != pos {
+			t.Fatalf("Select1(%d) = %d, %v, want %d", i, got, err, pos)
+		}
+	}
+}
+
+func TestGetBits(t *testing.T) {
+	s, bv := random(200)
+	for i := 0; i+64 <= 200; i += 7 {
+		for _, width := range []int{0, 1, 10, 64} {
+			var want uint64
+			for j := 0; j < width; j++ {
+				if s[i+j] == '1' {
+					want |= 1 << uint(j)
+				}
+			}
+			if got, err := bv.GetBits(i, width); err != nil || got != want {
+				t.Errorf("GetBits(%d, %d) = %#x, %v, want %#x", i, width, got, err, want)
+			}
+		}
+	}
+	if _, err := bv.GetBits(190, 11); err != ErrorOutOfRange {
+		t.Errorf("GetBits(190, 11) error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
+
+func TestBuilderBytes(t *testing.T) {
+	// Bits 0, 3, 9 and 15 of an 18-bit vector, with stray bits beyond the size.
+	lsb, msb := NewBuilder(18), NewBuilderMSBFirst(18)
+	for _, b := range []*Builder{lsb, msb} {
+		for _, i := range []int{0, 3, 9, 15, 20} {
+			b.Set1(i)
+		}
+	}
+
+	if got, want := lsb.Bytes(), []byte{0x09, 0x82, 0x00}; !slices.Equal(got, want) {
+		t.Errorf("LSB-first Bytes() = %#v, want %#v", got, want)
+	}
+	if got, want := msb.Bytes(), []byte{0x90, 0x41, 0x00}; !slices.Equal(got, want) {
+		t.Errorf("MSB-first Bytes() = %#v, want %#v", got, want)
+	}
+	if !lsb.Build().Equal(msb.Build()) {
+		t.Errorf("MSB-first and LSB-first builders built different vectors")
+	}
+}
+
+func TestSelect1FromEnd(t *testing.T) {
+	_, bv := random(500)
+	ones := bv.CountOnes()
+	for k := 0; k < ones; k++ {
+		want, _ := bv.Select1(ones - 1 - k)
+		if got, err := bv.Select1FromEnd(k); err != nil || got != want {
+			t.Errorf("Select1FromEnd(%d) = %d, %v, want %d", k, got, err, want)
+		}
+	}
+	for _, k := range []int{-1, ones} {
+		if _, err := bv.Select1FromEnd(k); err != ErrorNotExist {
+			t.Errorf("Select1FromEnd(%d) error = %v, want %v", k, err, ErrorNotExist)
+		}
+	}
+}
+
+func TestByteLen(t *testing.T) {
+	for _, c := range []struct{ size, want int }{{0, 0}, {1, 1}, {7, 1}, {8, 1}, {9, 2}, {64, 8}, {65, 9}} {
+		b := NewBuilder(c.size)
+		if got := b.ByteLen(); got != c.want {
+			t.Errorf("Builder.ByteLen() of size %d = %d, want %d", c.size, got, c.want)
+		}
+		if got := b.Build().ByteLen(); got != c.want {
+			t.Errorf("BitVector.ByteLen() of size %d = %d, want %d", c.size, got, c.want)
+		}
+		if got := len(b.Bytes()); got != c.want {
+			t.Errorf("len(Bytes()) of size %d = %d, want %d", c.size, got, c.want)
+		}
+	}
+}
+
+func TestAdaptiveSelect(t *testing.T) {
+	for _, density := range []float64{0, 0.001, 0.1, 0.9, 1} {
+		bv := randomDensity(100000, density)
+		if sparse := bv.ones != nil; sparse != (!bv.constant && bv.CountOnes()*sparseRatio <= bv.Len()) {
+			t.Errorf("density %g: kept positions = %v with %d 1s", density, sparse, bv.CountOnes())
+		}
+
+		search, positions := *bv, *bv
+		search.ones, positions.ones = nil, bv.Positions()
+		for i := 0; i < bv.CountOnes(); i += 1 + i/10 {
+			got, err := bv.Select1(i)
+			s, _ := search.Select1(i)
+			p, _ := positions.Select1(i)
+			if err != nil || got != s || got != p {
+				t.Errorf("density %g: Select1(%d) = %d, %v; search %d, positions %d", density, i, got, err, s, p)
+			}
+		}
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	b := NewBuilder(1000)
+	for i := 0; i < 1000; i += 4 {
+		b.Set1(i)
+	}
+	// p = 1/4: -0.25*log2(0.25) - 0.75*log2(0.75)
+	want := 0.5 + 0.75*0.4150374992788438
+	if got := b.Build().Entropy(); math.Abs(got-want) > 1e-12 {
+		t.Errorf("Entropy() = %v, want %v", got, want)
+	}
+
+	half := NewBuilder(10)
+	for i := 0; i < 5; i++ {
+		half.Set1(i)
+	}
+	if got := half.Build().Entropy(); got != 1 {
+		t.Errorf("Entropy() of half 1s = %v, want 1", got)
+	}
+
+	all := NewBuilder(100)
+	for i := 0; i < 100; i++ {
+		all.Set1(i)
+	}
+	for _, bv := range []*BitVector{NewBuilder(0).Build(), NewBuilder(100).Build(), all.Build()} {
+		if got := bv.Entropy(); got != 0 {
+			t.Errorf("Entropy() of a constant vector = %v, want 0", got)
+		}
+	}
+}
+
+func TestOrBytes(t *testing.T) {
+	const size = 150
+	frames := []struct {
+		data   []byte
+		offset int
+	}{
+		{[]byte{0xa5, 0x0f, 0xff}, 3},
+		{[]byte{0x81, 0x42, 0x24, 0x18, 0xff, 0x01}, 60},
+		{[]byte{0xff, 0xff, 0xff}, 130},
+	}
+
+	b := NewBuilder(size)
+	want := make([]bool, size)
+	for _, f := range frames {
+		b.OrBytes(f.data, f.offset)
+		for j, x := range f.data {
+			for k := 0; k < 8; k++ {
+				if pos := f.offset + j*8 + k; pos < size && x>>uint(k)&1 == 1 {
+					want[pos] = true
+				}
+			}
+		}
+	}
+
+	for i := range want {
+		if b.Get(i) != want[i] {
+			t.Errorf("Get(%d) = %v, want %v", i, b.Get(i), want[i])
+		}
+	}
+	if tail := b.v[size/bitLength] >> uint(size%bitLength); tail != 0 {
+		t.Errorf("OrBytes() set bits beyond the size: %#x", tail)
+	}
+}
+
+func TestNavigateDown(t *testing.T) {
+	_, bv := random(1000)
+	for i := 0; i < bv.Len(); i++ {
+		bit, _ := bv.Get(i)
+		want, _ := bv.Rank(i, bit)
+		child, isRight, err := bv.NavigateDown(i)
+		if err != nil || child != want || isRight != bit {
+			t.Errorf("NavigateDown(%d) = %d, %v, %v, want %d, %v", i, child, isRight, err, want, bit)
+		}
+	}
+	for _, i := range []int{-1, bv.Len()} {
+		if _, _, err := bv.NavigateDown(i); err != ErrorOutOfRange {
+			t.Errorf("NavigateDown(%d) error = %v, want %v", i, err, ErrorOutOfRange)
+		}
+	}
+}
+
+func TestBuilderSingleUse(t *testing.T) {
+	mustPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if r := recover(); r != "bitvector: Builder used after Build; call Reset to reuse it" {
+				t.Errorf("%s after Build: recovered %v", name, r)
+			}
+		}()
+		f()
+	}
+
+	b := NewBuilder(100)
+	b.Set1(3)
+	bv := b.Build()
+	mustPanic("Set", func() { b.Set1(4) })
+	mustPanic("Build", func() { b.Build() })
+	mustPanic("ShiftLeft", func() { b.ShiftLeft(1) })
+	if got, _ := bv.Get(4); got {
+		t.Errorf("Set after Build changed the built vector")
+	}
+
+	b.Reset()
+	b.Set1(4)
+	if got := b.Build(); got.CountOnes() != 1 || bv.CountOnes() != 1 {
+		t.Errorf("CountOnes() after Reset = %d, original %d, want 1, 1", got.CountOnes(), bv.CountOnes())
+	}
+
+	b.LoadFrom(bv)
+	b.Set1(5)
+	if got := b.Build(); got.CountOnes() != 2 {
+		t.Errorf("CountOnes() after LoadFrom = %d, want 2", got.CountOnes())
+	}
+}
+
+func TestPopcountWordRange(t *testing.T) {
+	s, _ := random(1000)
+	b := NewBuilder(len(s))
+	for i, c := range s {
+		b.Set(i, c == '1')
+	}
+	for start := 0; start <= len(b.v); start++ {
+		for end := start; end <= len(b.v); end++ {
+			want := 0
+			for k := start; k < end; k++ {
+				for j := 0; j < bitLength; j++ {
+					want += int(b.v[k] >> uint(j) & 1)
+				}
+			}
+			if got := b.PopcountWordRange(start, end); got != want {
+				t.Errorf("PopcountWordRange(%d, %d) = %d, want %d", start, end, got, want)
+			}
+		}
+	}
+}
+
+func TestGaps(t *testing.T) {
+	for _, size := range []int{0, 1, 100, 1000} {
+		_, bv := random(size)
+		gaps := bv.Gaps()
+		positions := bv.Positions()
+		if len(gaps) != len(positions) {
+			t.Fatalf("len(Gaps()) = %d, want %d", len(gaps), len(positions))
+		}
+		sum := 0
+		for i, gap := range gaps {
+			sum += gap
+			if sum != positions[i] {
+				t.Errorf("prefix sum %d of Gaps() = %d, want %d", i, sum, positions[i])
+			}
+		}
+	}
+}
+
+func TestCountMatching(t *testing.T) {
+	b := NewBuilder(12)
+	for i, c := range "101010011101" {
+		b.Set(i, c == '1')
+	}
+	bv := b.Build()
+	// Windows "101" start at 0, 2 and 9 of the 10 windows.
+	if got := bv.CountMatching(0b101, 3, true); got != 3 {
+		t.Errorf("CountMatching(101) = %d, want 3", got)
+	}
+	if got := bv.CountMatching(0b101, 3, false); got != 7 {
+		t.Errorf("CountMatching(101, false) = %d, want 7", got)
+	}
+	if got := bv.CountMatching(1, 1, true); got != bv.CountOnes() {
+		t.Errorf("CountMatching(1) = %d, want CountOnes() = %d", got, bv.CountOnes())
+	}
+
+	s, bv := random(1000)
+	for _, w := range []int{1, 2, 5, 17, 63, 64} {
+		pattern, _ := bv.GetBits(100, w)
+		want := 0
+		for p := 0; p+w <= len(s); p++ {
+			if got, _ := bv.GetBits(p, w); got == pattern {
+				want++
+			}
+		}
+		if got := bv.CountMatching(pattern, w, true); got != want {
+			t.Errorf("CountMatching(%#x, %d) = %d, want %d", pattern, w, got, want)
+		}
+	}
+	if got := bv.CountMatching(0, 64, true) + bv.CountMatching(0, 64, false); got != 1000-63 {
+		t.Errorf("count of 64-bit windows = %d, want %d", got, 1000-63)
+	}
+}
+
+func TestSetPositions(t *testing.T) {
+	const size = 100000
+	sorted := clustered(size)
+	random := make([]int, 1000)
+	for i := range random {
+		random[i] = rand.Intn(size)
+	}
+
+	for _, positions := range [][]int{nil, sorted, random, {size - 1, 0, size - 1}} {
+		b, want := NewBuilder(size), NewBuilder(size)
+		b.SetPositions(positions)
+		for _, pos := range positions {
+			want.Set1(pos)
+		}
+		if !b.Build().Equal(want.Build()) {
+			t.Errorf("SetPositions() of %d positions differs from Set1", len(positions))
+		}
+	}
+
+	// Positions out of range panic, past the size within the last word too,
+	// and a lenient builder ignores them.
+	for _, pos := range []int{-1, -64, 10, 40, 500} {
+		func() {
+			defer func() {
+				if r := recover(); r != "bitvector: SetPositions position out of range" {
+					t.Errorf("SetPositions([%d]) of size 10: recovered %v", pos, r)
+				}
+			}()
+			NewBuilder(10).SetPositions([]int{pos})
+		}()
+		b := NewBuilderLenient(10)
+		b.SetPositions([]int{3, pos, 9})
+		if got := b.Build().Positions(); !slices.Equal(got, []int{3, 9}) {
+			t.Errorf("lenient SetPositions([3 %d 9]) gives the 1s at %v, want [3 9]", pos, got)
+		}
+	}
+}
+
+func TestEstimateSizes(t *testing.T) {
+	sparse := NewBuilder(1000000)
+	for i := 0; i < 1000000; i += 9973 {
+		sparse.Set1(i)
+	}
+	sizes := sparse.Build().EstimateSizes()
+	for _, scheme := range []string{"raw", "rrr", "eliasfano", "gaps-varint", "runs"} {
+		if _, ok := sizes[scheme]; !ok {
+			t.Errorf("EstimateSizes() has no %q", scheme)
+		}
+	}
+	if sizes["raw"] != 125000 {
+		t.Errorf("raw = %d, want 125000", sizes["raw"])
+	}
+	if sizes["gaps-varint"] >= sizes["raw"] || sizes["eliasfano"] >= sizes["raw"] {
+		t.Errorf("EstimateSizes() of a sparse vector = %v, want gaps-varint and eliasfano below raw", sizes)
+	}
+	// 101 gaps of 9973 take 2 bytes each, except the first, 0, which takes 1.
+	if sizes["gaps-varint"] != 201 {
+		t.Errorf("gaps-varint = %d, want 201", sizes["gaps-varint"])
+	}
+
+	// A random vector is incompressible.
+	_, dense := random(100000)
+	sizes = dense.EstimateSizes()
+	if sizes["rrr"] < sizes["raw"] || sizes["eliasfano"] < sizes["raw"] {
+		t.Errorf("EstimateSizes() of a random vector = %v, want none below raw", sizes)
+	}
+}
+
+func TestSetAllClearAll(t *testing.T) {
+	for _, size := range []int{0, 1, 63, 64, 65, 1000} {
+		b := NewBuilder(size)
+		b.SetAll()
+		if tail := b.v[size/bitLength] >> uint(size%bitLength); tail != 0 {
+			t.Errorf("SetAll() of size %d set bits beyond the size: %#x", size, tail)
+		}
+		if b.PopcountWordRange(0, len(b.v)) != size {
+			t.Errorf("SetAll() of size %d set %d bits", size, b.PopcountWordRange(0, len(b.v)))
+		}
+		if bv := b.Build(); bv.CountOnes() != size {
+			t.Errorf("CountOnes() after SetAll() of size %d = %d", size, bv.CountOnes())
+		}
+
+		b = NewBuilder(size)
+		b.SetAll()
+		b.ClearAll()
+		if bv := b.Build(); bv.CountOnes() != 0 {
+			t.Errorf("CountOnes() after ClearAll() of size %d = %d", size, bv.CountOnes())
+		}
+	}
+}
+
+func TestParallelForEachSetBit(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 8, 1000} {
+		_, bv := random(10000)
+		var mu sync.Mutex
+		var got []int
+		bv.ParallelForEachSetBit(workers, func(pos int) {
+			mu.Lock()
+			got = append(got, pos)
+			mu.Unlock()
+		})
+		slices.Sort(got)
+		if !slices.Equal(got, bv.Positions()) {
+			t.Errorf("ParallelForEachSetBit(%d) visited %d positions, want %d", workers, len(got), bv.CountOnes())
+		}
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	s, plain := random(2000)
+	b := NewBuilder(len(s))
+	for i, c := range s {
+		b.Set(i, c == '1')
+	}
+	for _, bv := range []*BitVector{plain, b.BuildWithSelectIndex(true), randomDensity(2000, 0.001)} {
+		ones := bv.CountOnes()
+		zeros := bv.Len() - ones
+		for _, c := range []struct {
+			name string
+			f    func(int) (int, error)
+			i    int
+			want error
+		}{
+			{"Select1", bv.Select1, -1, ErrorOutOfRange},
+			{"Select1", bv.Select1, ones, ErrorNotExist},
+			{"Select1", bv.Select1, ones + 100, ErrorNotExist},
+			{"Select0", bv.Select0, -1, ErrorOutOfRange},
+			{"Select0", bv.Select0, zeros, ErrorNotExist},
+		} {
+			if _, err := c.f(c.i); err != c.want {
+				t.Errorf("%s(%d) error = %v, want %v", c.name, c.i, err, c.want)
+			}
+		}
+		if ones > 0 {
+			if _, err := bv.Select1(ones - 1); err != nil {
+				t.Errorf("Select1(%d) error = %v", ones-1, err)
+			}
+		}
+	}
+}
+
+func TestConstant(t *testing.T) {
+	for _, size := range []int{0, 1, 63, 64, 1000} {
+		for _, ones := range []bool{false, true} {
+			b := NewBuilder(size)
+			if ones {
+				b.SetAll()
+			}
+			bv := b.Build()
+			if size > 0 && (!bv.constant || bv.SizeInBytes() != 0) {
+				t.Fatalf("size %d, ones %v: constant = %v, SizeInBytes() = %d", size, ones, bv.constant, bv.SizeInBytes())
+			}
+
+			// A normally built equivalent stores the words and ranks.
+			v := bv.words()
+			rank := make([]int, len(v))
+			for k := 1; k < len(v); k++ {
+				rank[k] = rank[k-1] + bits.OnesCount64(v[k-1])
+			}
+			want := &BitVector{size: size, store: sliceStore(v), rank: rank}
+
+			sameQueries(t, bv, want)
+			for i := 0; i < size; i++ {
+				g, _ := bv.Get(i)
+				w, _ := want.Get(i)
+				if g != w {
+					t.Fatalf("size %d: Get(%d) = %v, want %v", size, i, g, w)
+				}
+				gr, gb, _ := bv.NavigateDown(i)
+				wr, wb, _ := want.NavigateDown(i)
+				if gr != wr || gb != wb {
+					t.Fatalf("size %d: NavigateDown(%d) = %d, %v, want %d, %v", size, i, gr, gb, wr, wb)
+				}
+				if g, _ := bv.Select0(i); !ones && g != i {
+					t.Fatalf("size %d: Select0(%d) = %d, want %d", size, i, g, i)
+				}
+			}
+			if size >= 10 {
+				g, _ := bv.GetBits(size-10, 10)
+				w, _ := want.GetBits(size-10, 10)
+				if g != w {
+					t.Errorf("size %d: GetBits() = %#x, want %#x", size, g, w)
+				}
+			}
+			if !bv.Equal(want) || !slices.Equal(bv.Bytes(LSBFirst), want.Bytes(LSBFirst)) {
+				t.Errorf("size %d, ones %v: differs from the normally built vector", size, ones)
+			}
+
+			data, _ := bv.MarshalBinary()
+			var got BitVector
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary() error = %v", err)
+			}
+			sameQueries(t, &got, want)
+		}
+	}
+}
+
+func TestBlockRanks(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 1000} {
+		_, bv := random(size)
+		all := NewBuilder(size)
+		all.SetAll()
+		for _, bv := range []*BitVector{bv, all.Build()} {
+			ranks := bv.BlockRanks()
+			if len(ranks) != size/bv.BlockBits()+1 {
+				t.Fatalf("size %d: len(BlockRanks()) = %d", size, len(ranks))
+			}
+			for k, r := range ranks {
+				if want, _ := bv.Rank1(k * bv.BlockBits()); r != want {
+					t.Errorf("size %d: BlockRanks()[%d] = %d, want %d", size, k, r, want)
+				}
+			}
+			if len(ranks) > 1 {
+				ranks[1] = -1
+				if bv.BlockRanks()[1] == -1 {
+					t.Errorf("size %d: BlockRanks() is not a copy", size)
+				}
+			}
+		}
+	}
+}
+
+func TestTestAndSet(t *testing.T) {
+	b := NewBuilder(100)
+	b.Set1(70)
+	for _, c := range []struct {
+		i    int
+		want bool
+	}{{5, false}, {5, true}, {70, true}, {99, false}, {64, false}} {
+		if got := b.TestAndSet(c.i); got != c.want {
+			t.Errorf("TestAndSet(%d) = %v, want %v", c.i, got, c.want)
+		}
+		if !b.Get(c.i) {
+			t.Errorf("Get(%d) = false after TestAndSet", c.i)
+		}
+	}
+	if got := b.Build().Positions(); !slices.Equal(got, []int{5, 64, 70, 99}) {
+		t.Errorf("Positions() = %v", got)
+	}
+}
+
+func TestBuildSIMDFriendly(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 511, 512, 513, 5000} {
+		s, want := random(size)
+		b := NewBuilder(size)
+		for i, c := range s {
+			b.Set(i, c == '1')
+		}
+		bv := b.BuildSIMDFriendly()
+		sameQueries(t, bv, want)
+		for i := 0; i < size; i++ {
+			g, gb, _ := bv.NavigateDown(i)
+			w, wb, _ := want.NavigateDown(i)
+			if g != w || gb != wb {
+				t.Fatalf("size %d: NavigateDown(%d) = %d, %v, want %d, %v", size, i, g, gb, w, wb)
+			}
+		}
+		if !slices.Equal(bv.BlockRanks(), want.BlockRanks()) {
+			t.Errorf("size %d: BlockRanks() differ", size)
+		}
+		if !want.constant && bv.SizeInBytes() >= want.SizeInBytes() {
+			t.Errorf("size %d: SizeInBytes() = %d, not below %d", size, bv.SizeInBytes(), want.SizeInBytes())
+		}
+
+		data, _ := bv.MarshalBinary()
+		got, err := decode(data, false)
+		if err != nil {
+			t.Fatalf("size %d: decode() error = %v", size, err)
+		}
+		sameQueries(t, got, want)
+	}
+}
+
+func TestBuildRank9(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 511, 512, 513, 5000} {
+		s, want := random(size)
+		b := NewBuilder(size)
+		for i, c := range s {
+			b.Set(i, c == '1')
+		}
+		bv := b.BuildRank9()
+		sameQueries(t, bv, want)
+		if !slices.Equal(bv.BlockRanks(), want.BlockRanks()) {
+			t.Errorf("size %d: BlockRanks() differ", size)
+		}
+		// A lone superblock's index is smaller in the default layout.
+		if !want.constant && size > superblockWords*bitLength && bv.SizeInBytes() >= want.SizeInBytes() {
+			t.Errorf("size %d: SizeInBytes() = %d, not below %d", size, bv.SizeInBytes(), want.SizeInBytes())
+		}
+
+		data, _ := bv.MarshalBinary()
+		got, err := decode(data, false)
+		if err != nil {
+			t.Fatalf("size %d: decode() error = %v", size, err)
+		}
+		if !want.constant && !slices.Equal(got.rank9, bv.rank9) {
+			t.Errorf("size %d: decode() lost the rank9 index", size)
+		}
+		sameQueries(t, got, want)
+	}
+
+	// A full superblock has counts up to 7 * 64 within it, which need all 9 bits.
+	b := NewBuilder(2000)
+	b.SetAll()
+	b.Set0(1999)
+	bv := b.BuildRank9()
+	for i := 0; i <= 2000; i++ {
+		if got, _ := bv.Rank1(i); got != min(i, 1999) {
+			t.Fatalf("Rank1(%d) = %d, want %d", i, got, min(i, 1999))
+		}
+	}
+}
+
+func TestBlockHistogram(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 1000} {
+		_, bv := random(size)
+		hist := bv.BlockHistogram()
+		sum := 0
+		for k, n := range hist {
+			if n < 0 || n > bv.BlockBits() {
+				t.Errorf("size %d: BlockHistogram()[%d] = %d", size, k, n)
+			}
+			sum += n
+		}
+		if sum != bv.CountOnes() {
+			t.Errorf("size %d: BlockHistogram() sums to %d, want %d", size, sum, bv.CountOnes())
+		}
+	}
+	b := NewBuilder(100)
+	b.SetPositions([]int{0, 64, 99})
+	if got := b.Build().BlockHistogram(); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("BlockHistogram() = %v, want [1 2]", got)
+	}
+}
+
+func TestNewBuilderWithHint(t *testing.T) {
+	for _, density := range []float64{0.001, 0.5} {
+		want := randomDensity(100000, density)
+		for _, hint := range []float64{0.001, 0.5} {
+			b := NewBuilderWithHint(want.Len(), hint)
+			b.SetPositions(want.Positions())
+			bv := b.Build()
+			sameQueries(t, bv, want)
+			if (bv.ones != nil) != (want.ones != nil) || !slices.Equal(bv.ones, want.ones) {
+				t.Errorf("density %g, hint %g: positions = %d, want %d", density, hint, len(bv.ones), len(want.ones))
+			}
+		}
+	}
+}
+
+func TestSelect0Index(t *testing.T) {
+	s, want := random(5000)
+	b := NewBuilder(len(s))
+	for i, c := range s {
+		b.Set(i, c == '1')
+	}
+	bv := b.BuildWithSelectIndex(false)
+	if bv.select0 == nil || bv.select1 != nil || !bv.HasSelectIndex() {
+		t.Fatalf("BuildWithSelectIndex(false) indexed 0s = %v, 1s = %v", bv.select0 != nil, bv.select1 != nil)
+	}
+	for i := 0; i < want.Len()-want.CountOnes(); i++ {
+		g, err := bv.Select0(i)
+		w, _ := want.Select0(i)
+		if err != nil || g != w {
+			t.Fatalf("Select0(%d) = %d, %v, want %d", i, g, err, w)
+		}
+	}
+	// Select1 falls back to the binary search.
+	for i := 0; i < want.CountOnes(); i++ {
+		g, err := bv.Select1(i)
+		w, _ := want.Select1(i)
+		if err != nil || g != w {
+			t.Fatalf("Select1(%d) = %d, %v, want %d", i, g, err, w)
+		}
+	}
+
+	data, _ := bv.MarshalBinary()
+	got, err := decode(data, false)
+	if err != nil || !slices.Equal(got.select0, bv.select0) {
+		t.Errorf("decode() = %v, %v, want the select index over 0s", got.select0, err)
+	}
+}
+
+func TestNewBuilderWithSelectIndex(t *testing.T) {
+	for _, density := range []float64{0, 0.001, 0.3, 0.9, 1} {
+		want := randomDensity(20000, density)
+		b := NewBuilderWithSelectIndex(want.Len())
+		b.SetPositions(want.Positions())
+		bv := b.Build()
+		if !bv.constant && (bv.select0 == nil || bv.select1 == nil && bv.ones == nil) {
+			t.Errorf("density %g: Build() indexed 0s = %v, 1s = %v", density, bv.select0 != nil, bv.select1 != nil)
+		}
+		for i := 0; i < want.CountOnes(); i++ {
+			g, err := bv.Select1(i)
+			w, _ := want.Select1(i)
+			if err != nil || g != w {
+				t.Fatalf("density %g: Select1(%d) = %d, %v, want %d", density, i, g, err, w)
+			}
+		}
+		for i := 0; i < want.Len()-want.CountOnes(); i++ {
+			g, err := bv.Select0(i)
+			w, _ := want.Select0(i)
+			if err != nil || g != w {
+				t.Fatalf("density %g: Select0(%d) = %d, %v, want %d", density, i, g, err, w)
+			}
+		}
+	}
+}
+
+func TestSelectInWord(t *testing.T) {
+	for range 1000 {
+		x := rand.Uint64()
+		r := 0
+		for pos := 0; pos < bitLength; pos++ {
+			if x>>uint(pos)&1 == 0 {
+				continue
+			}
+			if got, err := selectInWord(x, r); err != nil || got != pos {
+				t.Fatalf("selectInWord(%#x, %d) = %d, %v, want %d", x, r, got, err, pos)
+			}
+			r++
+		}
+		if _, err := selectInWord(x, r); err != ErrorInvalidFormat {
+			t.Fatalf("selectInWord(%#x, %d) error = %v, want %v", x, r, err, ErrorInvalidFormat)
+		}
+	}
+}
+
+func TestSizeOverflow(t *testing.T) {
+	mustPanic := func(name string, want string, f func()) {
+		t.Helper()
+		defer func() {
+			if r := recover(); r != want {
+				t.Errorf("%s: recovered %v, want %q", name, r, want)
+			}
+		}()
+		f()
+	}
+
+	// Each guard triggers before an overflowing product allocates anything.
+	mustPanic("NewBuilder(maxInt)", "bitvector: Builder size out of range", func() { NewBuilder(maxInt) })
+	mustPanic("NewBuilder(-1)", "bitvector: Builder size out of range", func() { NewBuilder(-1) })
+	mustPanic("NewPackedArray", "bitvector: PackedArray size out of range", func() { NewPackedArray[uint8](maxInt/4, 8) })
+	mustPanic("NewMatrixBuilder", "bitvector: BitMatrix size out of range", func() { NewMatrixBuilder(maxInt/2+1, 2) })
+}
+
+func TestNewFromRuns(t *testing.T) {
+	runs := [][2]int{{3, 5}, {60, 10}, {65, 70}, {135, 1}, {200, 0}, {299, 1}}
+	bv, err := NewFromRuns(runs, 300)
+	if err != nil {
+		t.Fatalf("NewFromRuns(): %v", err)
+	}
+	want := make([]bool, 300)
+	for _, run := range runs {
+		for i := run[0]; i < run[0]+run[1]; i++ {
+			want[i] = true
+		}
+	}
+	for i, w := range want {
+		if got, _ := bv.Get(i); got != w {
+			t.Errorf("Get(%d) = %v, want %v", i, got, w)
+		}
+	}
+	// [60, 135) and [135, 136) touch and form one run.
+	if got := bv.CountOnes(); got != 5+76+1 {
+		t.Errorf("CountOnes() = %d, want %d", got, 5+76+1)
+	}
+	if got := bv.RunCount1(); got != 3 {
+		t.Errorf("RunCount1() = %d, want 3", got)
+	}
+
+	for _, run := range [][2]int{{-1, 2}, {299, 2}, {0, -1}, {0, 301}} {
+		if _, err := NewFromRuns([][2]int{run}, 300); err != ErrorOutOfRange {
+			t.Errorf("NewFromRuns(%v) error = %v, want %v", run, err, ErrorOutOfRange)
+		}
+	}
+}
+
+func TestRunCount1(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 65, 1000} {
+		s, bv := random(size)
+		want := 0
+		for i := range s {
+			if s[i] == '1' && (i == 0 || s[i-1] == '0') {
+				want++
+			}
+		}
+		if got := bv.RunCount1(); got != want {
+			t.Errorf("size %d: RunCount1() = %d, want %d", size, got, want)
+		}
+	}
+}
+
+func TestToggleRange(t *testing.T) {
+	const size = 300
+	s, want := random(size)
+	newBuilder := func() *Builder {
+		b := NewBuilder(size)
+		for i, c := range s {
+			b.Set(i, c == '1')
+		}
+		return b
+	}
+
+	b := newBuilder()
+	b.ToggleRange(0, size)
+	b.ToggleRange(0, size)
+	sameQueries(t, b.Build(), want)
+
+	r := rand.New(rand.NewSource(1))
+	for range 200 {
+		i := r.Intn(size + 1)
+		j := i + r.Intn(size+1-i)
+		ref := []byte(s)
+		for k := i; k < j; k++ {
+			ref[k] ^= '0' ^ '1'
+		}
+		for _, flip := range []func(*Builder, int, int){(*Builder).ToggleRange, (*Builder).FlipRange} {
+			b := newBuilder()
+			flip(b, i, j)
+			for k := range size {
+				if got := b.Get(k); got != (ref[k] == '1') {
+					t.Fatalf("after flipping [%d, %d): Get(%d) = %v", i, j, k, got)
+				}
+			}
+			if bv := b.Build(); bv.CountOnes() != len(positionsOf(string(ref))) {
+				t.Fatalf("after flipping [%d, %d): CountOnes() = %d", i, j, bv.CountOnes())
+			}
+		}
+	}
+}
+
+func TestSelectHints(t *testing.T) {
+	for _, size := range []int{1, 63, 64, 511, 512, 513, 5000} {
+		for _, density := range []float64{0.01, 0.5, 0.99} {
+			hinted := randomDensity(size, density)
+			if hinted.constant || hinted.ones != nil {
+				continue
+			}
+			if len(hinted.hints) != (size/bitLength+hintWords)/hintWords {
+				t.Fatalf("size %d: len(hints) = %d", size, len(hinted.hints))
+			}
+			unhinted := *hinted
+			unhinted.hints = nil
+			for i := 0; i < hinted.CountOnes(); i++ {
+				g, err := hinted.Select1(i)
+				w, _ := unhinted.Select1(i)
+				if err != nil || g != w {
+					t.Fatalf("size %d, density %g: Select1(%d) = %d, %v, want %d", size, density, i, g, err, w)
+				}
+			}
+		}
+	}
+}
+
+func TestPositionsU32(t *testing.T) {
+	_, bv := random(1000)
+	got, err := bv.PositionsU32()
+	if err != nil {
+		t.Fatalf("PositionsU32(): %v", err)
+	}
+	want := bv.Positions()
+	if len(got) != len(want) {
+		t.Fatalf("len(PositionsU32()) = %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if int(got[i]) != want[i] {
+			t.Errorf("PositionsU32()[%d] = %d, want %d", i, got[i], want[i])
+		}
+	}
+
+	// A constant vector needs no words, so an oversized one costs nothing.
+	size := uint64(math.MaxUint32) + 1
+	if uint64(int(size)) != size {
+		t.Skip("int cannot hold the oversized size")
+	}
+	oversized := BitVector{size: int(size), constant: true}
+	if _, err := oversized.PositionsU32(); err != ErrorOutOfRange {
+		t.Errorf("PositionsU32() of size %d error = %v, want %v", size, err, ErrorOutOfRange)
+	}
+}
+
+func TestNewBuilderLenient(t *testing.T) {
+	b := NewBuilderLenient(100)
+	b.Set1(3)
+	b.Set(100+5, true)
+	b.Set(100, true)
+	b.Set1(127)
+	b.Set1(-1)
+	b.Set0(1000)
+	if bv := b.Build(); bv.CountOnes() != 1 || bv.Len() != 100 {
+		t.Errorf("CountOnes(), Len() = %d, %d, want 1, 100", bv.CountOnes(), bv.Len())
+	}
+	if b.v[1] != 0 {
+		t.Errorf("Set out of range wrote %#x beyond the size", b.v[1])
+	}
+}
+
+// fakeStore is a wordStore that is not a sliceStore, counting its reads.
+type fakeStore struct {
+	words []uint64
+	reads int
+}
+
+func (s *fakeStore) Word(k int) uint64 {
+	s.reads++
+	return s.words[k]
+}
+
+func (s *fakeStore) Len() int {
+	return len(s.words)
+}
+
+func TestWordStore(t *testing.T) {
+	for _, size := range []int{1, 64, 1000, 5000} {
+		s, want := random(size)
+		for _, build := range []func(*Builder) *BitVector{(*Builder).Build, (*Builder).BuildSIMDFriendly, (*Builder).BuildRank9} {
+			b := NewBuilder(size)
+			for i, c := range s {
+				b.Set(i, c == '1')
+			}
+			bv := build(b)
+			if bv.constant {
+				continue
+			}
+			store := &fakeStore{words: slices.Clone(bv.words())}
+			bv.store = store
+
+			sameQueries(t, bv, want)
+			for i := 0; i < size; i++ {
+				g, _ := bv.Get(i)
+				w, _ := want.Get(i)
+				gr, gb, _ := bv.NavigateDown(i)
+				wr, wb, _ := want.NavigateDown(i)
+				if g != w || gr != wr || gb != wb {
+					t.Fatalf("size %d: Get(%d), NavigateDown(%d) = %v, %d, %v, want %v, %d, %v", size, i, i, g, gr, gb, w, wr, wb)
+				}
+				width := min(bitLength, size-i)
+				gx, _ := bv.GetBits(i, width)
+				wx, _ := want.GetBits(i, width)
+				if gx != wx {
+					t.Fatalf("size %d: GetBits(%d, %d) = %#x, want %#x", size, i, width, gx, wx)
+				}
+			}
+			if !bv.Equal(want) || !slices.Equal(bv.Bytes(LSBFirst), want.Bytes(LSBFirst)) {
+				t.Errorf("size %d: differs through the store", size)
+			}
+			if data, _ := bv.MarshalBinary(); len(data) == 0 {
+				t.Errorf("size %d: MarshalBinary() is empty", size)
+			}
+			if store.reads == 0 {
+				t.Errorf("size %d: the store was never read", size)
+			}
+		}
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	for _, size := range []int{0, 1, 63, 64, 65, 1000} {
+		s, bv := random(size)
+		var want []int
+		for i := 1; i < size; i++ {
+			if s[i] != s[i-1] {
+				want = append(want, i)
+			}
+		}
+		got := slices.Collect(bv.Transitions())
+		if !slices.Equal(got, want) {
+			t.Fatalf("size %d: Transitions() = %v, want %v", size, got, want)
+		}
+
+		// Each run of 1s starts at the first bit or at a transition to 1.
+		starts := 0
+		if size > 0 && s[0] == '1' {
+			starts++
+		}
+		for _, i := range got {
+			if s[i] == '1' {
+				starts++
+			}
+		}
+		if bv.RunCount1() != starts {
+			t.Errorf("size %d: RunCount1() = %d, want %d from the transitions", size, bv.RunCount1(), starts)
+		}
+	}
+
+	all := NewBuilder(130)
+	all.SetAll()
+	if got := slices.Collect(all.Build().Transitions()); len(got) != 0 {
+		t.Errorf("Transitions() of all 1s = %v, want none", got)
+	}
+}
+
+func TestRankPadded(t *testing.T) {
+	_, bv := random(1000)
+	for i := 0; i <= 1500; i++ {
+		got, err := bv.RankPadded(i, 1500)
+		want, _ := bv.Rank1(min(i, 1000))
+		if err != nil || got != want {
+			t.Errorf("RankPadded(%d, 1500) = %d, %v, want %d", i, got, err, want)
+		}
+	}
+	for _, c := range [][2]int{{-1, 1500}, {1501, 1500}, {10, 999}} {
+		if _, err := bv.RankPadded(c[0], c[1]); err != ErrorOutOfRange {
+			t.Errorf("RankPadded(%d, %d) error = %v, want %v", c[0], c[1], err, ErrorOutOfRange)
+		}
+	}
+}
+
+func TestNotBuilt(t *testing.T) {
+	var bv BitVector
+	if _, err := bv.Rank1(0); err != ErrorNotBuilt {
+		t.Errorf("Rank1(0) of the zero value error = %v, want %v", err, ErrorNotBuilt)
+	}
+	if _, err := bv.Get(0); err != ErrorNotBuilt {
+		t.Errorf("Get(0) of the zero value error = %v, want %v", err, ErrorNotBuilt)
+	}
+	if _, err := bv.Select1(0); err != ErrorNotBuilt {
+		t.Errorf("Select1(0) of the zero value error = %v, want %v", err, ErrorNotBuilt)
+	}
+	if _, err := NewBuilder(0).Build().Rank1(0); err != nil {
+		t.Errorf("Rank1(0) of an empty vector error = %v", err)
+	}
+	if _, err := NewRankWindow(&bv).Rank1(0); err != ErrorNotBuilt {
+		t.Errorf("RankWindow.Rank1(0) of the zero value error = %v, want %v", err, ErrorNotBuilt)
+	}
+
+	// The methods without an error see the zero value as empty.
+	if got := bv.Positions(); len(got) != 0 {
+		t.Errorf("Positions() of the zero value = %v", got)
+	}
+	if got, err := bv.PositionsU32(); len(got) != 0 || err != nil {
+		t.Errorf("PositionsU32() of the zero value = %v, %v", got, err)
+	}
+	for pos, rank := range bv.PositionRankPairs() {
+		t.Errorf("PositionRankPairs() of the zero value yields %d, %d", pos, rank)
+	}
+	for pos := range bv.Transitions() {
+		t.Errorf("Transitions() of the zero value yields %d", pos)
+	}
+	if got := bv.RunCount1(); got != 0 {
+		t.Errorf("RunCount1() of the zero value = %d", got)
+	}
+	var buf strings.Builder
+	if err := bv.WriteASCII(&buf, 8); err != nil || buf.Len() != 0 {
+		t.Errorf("WriteASCII() of the zero value wrote %q, %v", buf.String(), err)
+	}
+	if got := bv.Bytes(LSBFirst); len(got) != 0 {
+		t.Errorf("Bytes() of the zero value = %v", got)
+	}
+	if got := bv.MarshalDataOnly(); len(got) != 8 {
+		t.Errorf("MarshalDataOnly() of the zero value = %v", got)
+	}
+	if got := bv.EstimateSizes(); got["raw"] != 0 || got["runs"] != 0 {
+		t.Errorf("EstimateSizes() of the zero value = %v", got)
+	}
+	if got := bv.WithWordReplaced(0, 1); got.Len() != 0 || got.CountOnes() != 0 {
+		t.Errorf("WithWordReplaced(0, 1) of the zero value has %d bits and %d 1s", got.Len(), got.CountOnes())
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	b := NewBuilder(10)
+	b.SetPositions([]int{0, 1, 2, 4, 8, 9})
+	bv := b.Build()
+	for _, c := range []struct {
+		factor   int
+		anyOrAll bool
+		want     []int
+	}{
+		{3, false, []int{0, 1, 2, 3}}, // 111 010 001 1
+		{3, true, []int{0, 3}},
+		{4, false, []int{0, 1, 2}}, // 1110 1000 11
+		{4, true, []int{2}},
+		{1, true, []int{0, 1, 2, 4, 8, 9}},
+		{20, false, []int{0}},
+	} {
+		got := bv.Downsample(c.factor, c.anyOrAll)
+		if want := (10 + c.factor - 1) / c.factor; got.Len() != want {
+			t.Errorf("Downsample(%d, %v).Len() = %d, want %d", c.factor, c.anyOrAll, got.Len(), want)
+		}
+		if !slices.Equal(got.Positions(), c.want) {
+			t.Errorf("Downsample(%d, %v) = %v, want %v", c.factor, c.anyOrAll, got.Positions(), c.want)
+		}
+	}
+}
+
+func TestWithWordReplaced(t *testing.T) {
+	for _, size := range []int{1, 64, 100, 1000} {
+		s, _ := random(size)
+		var vs []*BitVector
+		for _, build := range []func(*Builder) *BitVector{(*Builder).Build, (*Builder).BuildSIMDFriendly, (*Builder).BuildRank9, (*Builder).BuildInterleaved, func(b *Builder) *BitVector { return b.BuildWithSelectIndex(true) }} {
+			b := NewBuilder(size)
+			for i, c := range s {
+				b.Set(i, c == '1')
+			}
+			vs = append(vs, build(b))
+		}
+		vs = append(vs, randomDensity(size, 0.001), NewBuilder(size).Build())
+
+		for _, bv := range vs {
+			for k := range bv.numWords() {
+				word, orig := rand.Uint64(), bv.word(k)
+				got := bv.WithWordReplaced(k, word)
+				if bv.word(k) != orig {
+					t.Fatalf("WithWordReplaced() changed the original")
+				}
+
+				b := NewBuilder(size)
+				b.LoadFrom(bv)
+				b.SetBits(k*bitLength, min(bitLength, size-k*bitLength), word)
+				sameQueries(t, got, b.Build())
+			}
+		}
+	}
+}
+
+func TestGetBitReversed(t *testing.T) {
+	_, bv := random(1000)
+	reverse := func(i, n int) int {
+		j := i >> uint(n) << uint(n)
+		for k := range n {
+			if i>>uint(k)&1 == 1 {
+				j |= 1 << uint(n-1-k)
+			}
+		}
+		return j
+	}
+	for _, addressBits := range []int{0, 1, 3, 8, 9} {
+		for i := range 1000 {
+			j := reverse(i, addressBits)
+			got, err := bv.GetBitReversed(i, addressBits)
+			want, wantErr := bv.Get(j)
+			if got != want || err != wantErr {
+				t.Fatalf("GetBitReversed(%d, %d) = %v, %v, want Get(%d) = %v, %v", i, addressBits, got, err, j, want, wantErr)
+			}
+		}
+	}
+	if _, err := bv.GetBitReversed(1, -1); err != ErrorOutOfRange {
+		t.Errorf("GetBitReversed(1, -1) error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
+
+func TestSample(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	_, bv := random(1000)
+	ones := bv.CountOnes()
+	for _, k := range []int{0, 1, 100, ones, ones + 10} {
+		sampled := bv.Sample(k, rng)
+		if sampled.Len() != bv.Len() || sampled.CountOnes() != min(k, ones) {
+			t.Errorf("Sample(%d): Len(), CountOnes() = %d, %d, want %d, %d", k, sampled.Len(), sampled.CountOnes(), bv.Len(), min(k, ones))
+		}
+		if both, _ := And(sampled, bv); both.CountOnes() != sampled.CountOnes() {
+			t.Errorf("Sample(%d) kept bits that were not set", k)
+		}
+	}
+}
+
+func TestFindClose(t *testing.T) {
+	// (()(()))() and an unmatched (
+	parens := "(()(()))()("
+	b := NewBuilder(len(parens))
+	for i, c := range parens {
+		b.Set(i, c == '(')
+	}
+	bv := b.Build()
+	for open, want := range map[int]int{0: 7, 1: 2, 3: 6, 4: 5, 8: 9} {
+		if got, err := bv.FindClose(open); err != nil || got != want {
+			t.Errorf("FindClose(%d) = %d, %v, want %d", open, got, err, want)
+		}
+	}
+	for _, open := range []int{10, 2} {
+		if _, err := bv.FindClose(open); err != ErrorNotExist {
+			t.Errorf("FindClose(%d) error = %v, want %v", open, err, ErrorNotExist)
+		}
+	}
+	if _, err := bv.FindClose(11); err != ErrorOutOfRange {
+		t.Errorf("FindClose(11) error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
+
+func TestTwoLevelRank(t *testing.T) {
+	for _, size := range []int{1, 64, 511, 512, 513, 5000} {
+		s, bv := random(size)
+		if bv.constant {
+			continue
+		}
+		// The per-word ranks of older releases are still answered.
+		v := bv.words()
+		rank := make([]int, len(v))
+		for k := 1; k < len(v); k++ {
+			rank[k] = rank[k-1] + bits.OnesCount64(v[k-1])
+		}
+		perWord := &BitVector{size: size, store: sliceStore(v), rank: rank}
+
+		ones := 0
+		for i := 0; i <= size; i++ {
+			got, _ := bv.Rank1(i)
+			want, _ := perWord.Rank1(i)
+			if got != ones || want != ones {
+				t.Fatalf("size %d: Rank1(%d) = %d, per word %d, want %d", size, i, got, want, ones)
+			}
+			if i < size && s[i] == '1' {
+				ones++
+			}
+		}
+
+		words := size/bitLength + 1
+		if want := 8*((words+superblockWords-1)/superblockWords) + 2*words; len(bv.block) != words || 8*len(bv.rank)+2*len(bv.block) != want {
+			t.Errorf("size %d: rank index of %d superblocks and %d blocks", size, len(bv.rank), len(bv.block))
+		}
+	}
+}
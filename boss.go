@@ -0,0 +1,259 @@
+package bitvector
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// BOSS is a de Bruijn graph of order k, whose nodes are k-mers and whose
+// edges are the (k+1)-mers, encoded as in Bowe, Onodera, Sadakane and Shibuya.
+//
+// The nodes are sorted by their k-mers read right to left and each lists its
+// outgoing edges by their last byte, the label. An edge is flagged if an
+// earlier node ending with the same k-1 bytes has an edge of the same
+// label, so that the unflagged edges of each label lead to the nodes ending
+// with it in order. Nodes without an incoming edge are reached from nodes
+// padded with 0 bytes on the left, and nodes without an outgoing edge have an
+// edge labeled 0, so byte 0 may not occur in the k-mers.
+type BOSS struct {
+	k       int
+	last    *BitVector     // marks the last edge of each node.
+	flagged *BitVector     // marks the flagged edges.
+	labels  *WaveletMatrix // the labels of the unflagged edges.
+	flags   *WaveletMatrix // the labels of the flagged edges.
+	c       [257]int       // the count of nodes ending with a byte less than each byte.
+}
+
+// NewBOSS makes a BOSS of the edges, given as (k+1)-mers for a k of at
+// least 1. It returns ErrorSizeMismatch if they are not of the same length
+// and ErrorInvalidFormat if one is shorter than 2 or holds byte 0.
+func NewBOSS(edges []string) (*BOSS, error) {
+	if len(edges) == 0 {
+		return nil, ErrorInvalidFormat
+	}
+	k := len(edges[0]) - 1
+	for _, e := range edges {
+		if len(e) != k+1 {
+			return nil, ErrorSizeMismatch
+		}
+		if k < 1 || strings.IndexByte(e, 0) >= 0 {
+			return nil, ErrorInvalidFormat
+		}
+	}
+
+	// The outgoing labels of each node, padding those without incoming edges.
+	out := map[string][]byte{}
+	incoming := map[string]bool{}
+	for _, e := range edges {
+		out[e[:k]] = append(out[e[:k]], e[k])
+		incoming[e[1:]] = true
+	}
+	for _, e := range edges {
+		if _, ok := out[e[1:]]; !ok {
+			out[e[1:]] = nil
+		}
+	}
+	var sources []string
+	for x := range out {
+		if !incoming[x] {
+			sources = append(sources, x)
+		}
+	}
+	for _, x := range sources {
+		for i := 1; i <= k; i++ {
+			padded := strings.Repeat("\x00", i) + x[:k-i]
+			out[padded] = append(out[padded], x[k-i])
+		}
+	}
+
+	// Sort the nodes by their reversed k-mers.
+	reversed := make([][]byte, 0, len(out))
+	for x := range out {
+		r := []byte(x)
+		slices.Reverse(r)
+		reversed = append(reversed, r)
+	}
+	slices.SortFunc(reversed, bytes.Compare)
+	nodes := make([]string, len(reversed))
+	for v, r := range reversed {
+		slices.Reverse(r)
+		nodes[v] = string(r)
+	}
+
+	var labels, flags []byte
+	var lasts, flagged []bool
+	var seen [256]bool // the labels of the nodes ending with the k-1 bytes of the current node.
+	for v, x := range nodes {
+		if v > 0 && nodes[v-1][1:] != x[1:] {
+			seen = [256]bool{}
+		}
+		outs := slices.Compact(slices.Sorted(slices.Values(out[x])))
+		if len(outs) == 0 {
+			outs = []byte{0}
+		}
+		for i, label := range outs {
+			lasts = append(lasts, i == len(outs)-1)
+			flagged = append(flagged, label != 0 && seen[label])
+			if label != 0 && seen[label] {
+				flags = append(flags, label)
+			} else {
+				labels = append(labels, label)
+			}
+			seen[label] = true
+		}
+	}
+
+	g := &BOSS{k: k, labels: NewWaveletMatrix(labels), flags: NewWaveletMatrix(flags)}
+	l := NewBuilderWithSelectIndex(len(lasts))
+	f := NewBuilderWithSelectIndex(len(flagged))
+	for i := range lasts {
+		l.Set(i, lasts[i])
+		f.Set(i, flagged[i])
+	}
+	g.last, g.flagged = l.Build(), f.Build()
+	for _, x := range nodes {
+		g.c[int(x[k-1])+1]++
+	}
+	for x := 1; x <= 256; x++ {
+		g.c[x] += g.c[x-1]
+	}
+	return g, nil
+}
+
+// Len returns the number of nodes, including those padded with 0 bytes.
+func (g BOSS) Len() int {
+	return g.c[256]
+}
+
+// K returns the length of the k-mers of the nodes.
+func (g BOSS) K() int {
+	return g.k
+}
+
+// edges returns the first edge of node v, or the number of edges if v is Len.
+func (g BOSS) edges(v int) int {
+	if v == 0 {
+		return 0
+	}
+	i, _ := g.last.Select1(v - 1)
+	return i + 1
+}
+
+// node returns the node of edge i.
+func (g BOSS) node(i int) int {
+	v, _ := g.last.Rank1(i)
+	return v
+}
+
+// label returns the label of edge i.
+func (g BOSS) label(i int) byte {
+	if x, _ := g.flagged.Get(i); x {
+		j, _ := g.flagged.Rank1(i)
+		label, _ := g.flags.Access(j)
+		return label
+	}
+	j, _ := g.flagged.Rank0(i)
+	label, _ := g.labels.Access(j)
+	return label
+}
+
+// rank returns the count of unflagged edges labeled x before edge i.
+func (g BOSS) rank(x byte, i int) int {
+	j, _ := g.flagged.Rank0(i)
+	count, _ := g.labels.Rank(x, j)
+	return count
+}
+
+// unflagged returns the r-th unflagged edge labeled x, or the number of edges if there is none.
+func (g BOSS) unflagged(x byte, r int) int {
+	j, err := g.labels.Select(x, r)
+	if err != nil {
+		return g.edges(g.Len())
+	}
+	i, _ := g.flagged.Select0(j)
+	return i
+}
+
+// lastByte returns the last byte of the k-mer of node v.
+func (g BOSS) lastByte(v int) byte {
+	return byte(sort.Search(256, func(x int) bool { return g.c[x+1] > v }))
+}
+
+// Node returns the node of kmer, or false if it is not in the graph.
+func (g BOSS) Node(kmer string) (int, bool) {
+	if len(kmer) != g.k {
+		return 0, false
+	}
+	// The range of nodes ending with the bytes of kmer so far.
+	s, e := 0, g.Len()
+	for i := 0; i < len(kmer) && s < e; i++ {
+		x := kmer[i]
+		if x == 0 {
+			return 0, false
+		}
+		s, e = g.c[x]+g.rank(x, g.edges(s)), g.c[x]+g.rank(x, g.edges(e))
+	}
+	if s >= e {
+		return 0, false
+	}
+	return s, true
+}
+
+// Label returns the k-mer of node v, padded with 0 bytes on the left for
+// the nodes leading to those without an incoming edge.
+func (g BOSS) Label(v int) (string, error) {
+	if v < 0 || v >= g.Len() {
+		return "", ErrorOutOfRange
+	}
+	kmer := make([]byte, g.k)
+	for i := g.k - 1; i >= 0; i-- {
+		x := g.lastByte(v)
+		if x == 0 {
+			break // the rest is padding.
+		}
+		kmer[i] = x
+		v = g.node(g.unflagged(x, v-g.c[x]))
+	}
+	return string(kmer), nil
+}
+
+// Outgoing returns the node reached from node v by the edge labeled x. It
+// returns ErrorNotExist if there is no such edge.
+func (g BOSS) Outgoing(v int, x byte) (int, error) {
+	if v < 0 || v >= g.Len() {
+		return 0, ErrorOutOfRange
+	}
+	for i := g.edges(v); i < g.edges(v+1); i++ {
+		if label := g.label(i); label == x && x != 0 {
+			// The unflagged edge of the label at or before i leads to the same node.
+			return g.c[x] + g.rank(x, i+1) - 1, nil
+		}
+	}
+	return 0, ErrorNotExist
+}
+
+// Incoming returns the nodes with an edge to node v in ascending order.
+func (g BOSS) Incoming(v int) ([]int, error) {
+	if v < 0 || v >= g.Len() {
+		return nil, ErrorOutOfRange
+	}
+	x := g.lastByte(v)
+	if x == 0 {
+		return nil, nil
+	}
+	// The unflagged edge to v, then the flagged edges of the label until the next unflagged one.
+	first, end := g.unflagged(x, v-g.c[x]), g.unflagged(x, v-g.c[x]+1)
+	nodes := []int{g.node(first)}
+	s, _ := g.flagged.Rank1(first)
+	e, _ := g.flagged.Rank1(end)
+	s, _ = g.flags.Rank(x, s)
+	e, _ = g.flags.Rank(x, e)
+	for t := s; t < e; t++ {
+		j, _ := g.flags.Select(x, t)
+		i, _ := g.flagged.Select1(j)
+		nodes = append(nodes, g.node(i))
+	}
+	return nodes, nil
+}
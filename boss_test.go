@@ -0,0 +1,133 @@
+package bitvector
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// randomEdges returns the (k+1)-mers of random reads over ACGT.
+func randomEdges(k, reads, length int) []string {
+	var edges []string
+	for range reads {
+		var read strings.Builder
+		for range length {
+			read.WriteByte("ACGT"[rand.Intn(4)])
+		}
+		for i := 0; i+k+1 <= length; i++ {
+			edges = append(edges, read.String()[i:i+k+1])
+		}
+	}
+	return edges
+}
+
+func TestBOSS(t *testing.T) {
+	for _, k := range []int{1, 2, 3, 5} {
+		edges := randomEdges(k, 20, 30)
+		g, err := NewBOSS(edges)
+		if err != nil {
+			t.Fatalf("NewBOSS(): %v", err)
+		}
+		if g.K() != k {
+			t.Errorf("K() = %d, want %d", g.K(), k)
+		}
+		set := map[string]bool{}
+		kmers := map[string]bool{}
+		for _, e := range edges {
+			set[e] = true
+			kmers[e[:k]], kmers[e[1:]] = true, true
+		}
+		for kmer := range kmers {
+			v, ok := g.Node(kmer)
+			if !ok {
+				t.Fatalf("k=%d: Node(%q) = false", k, kmer)
+			}
+			if got, err := g.Label(v); err != nil || got != kmer {
+				t.Errorf("Label(Node(%q)) = %q, %v", kmer, got, err)
+			}
+			for _, x := range []byte("ACGT") {
+				w, err := g.Outgoing(v, x)
+				if !set[kmer+string(x)] {
+					if err != ErrorNotExist {
+						t.Errorf("Outgoing(%q, %c) error = %v, want ErrorNotExist", kmer, x, err)
+					}
+					continue
+				}
+				if want, _ := g.Node(kmer[1:] + string(x)); err != nil || w != want {
+					t.Errorf("Outgoing(%q, %c) = %d, %v, want %d", kmer, x, w, err, want)
+				}
+			}
+
+			var want []string
+			for _, x := range []byte("ACGT") {
+				if set[string(x)+kmer] {
+					want = append(want, string(x)+kmer[:k-1])
+				}
+			}
+			if len(want) == 0 {
+				want = []string{"\x00" + kmer[:k-1]}
+			}
+			nodes, err := g.Incoming(v)
+			if err != nil || !slices.IsSorted(nodes) {
+				t.Errorf("Incoming(%q) = %v, %v", kmer, nodes, err)
+			}
+			var got []string
+			for _, w := range nodes {
+				label, _ := g.Label(w)
+				got = append(got, label)
+			}
+			slices.Sort(got)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Errorf("k=%d: labels of Incoming(%q) = %q, want %q", k, kmer, got, want)
+			}
+		}
+		for _, kmer := range []string{strings.Repeat("N", k), strings.Repeat("A", k+1), ""} {
+			if _, ok := g.Node(kmer); ok {
+				t.Errorf("Node(%q) = true", kmer)
+			}
+		}
+	}
+}
+
+func TestBOSSPadding(t *testing.T) {
+	g, err := NewBOSS([]string{"ACG", "CGT"})
+	if err != nil {
+		t.Fatalf("NewBOSS(): %v", err)
+	}
+	// The nodes AC, CG and GT, with $$ and $A leading to AC.
+	if g.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", g.Len())
+	}
+	root, _ := g.Outgoing(0, 'A')
+	if label, _ := g.Label(root); label != "\x00A" {
+		t.Errorf("Label(Outgoing(0, A)) = %q, want \\x00A", label)
+	}
+	if nodes, _ := g.Incoming(0); len(nodes) != 0 {
+		t.Errorf("Incoming(0) = %v, want none", nodes)
+	}
+	v, _ := g.Node("GT")
+	if _, err := g.Outgoing(v, 0); err != ErrorNotExist {
+		t.Errorf("Outgoing(GT, 0) error = %v, want ErrorNotExist", err)
+	}
+	if _, err := g.Label(g.Len()); err != ErrorOutOfRange {
+		t.Errorf("Label(Len()) error = %v, want ErrorOutOfRange", err)
+	}
+}
+
+func TestNewBOSSErrors(t *testing.T) {
+	for _, c := range []struct {
+		edges []string
+		want  error
+	}{
+		{nil, ErrorInvalidFormat},
+		{[]string{"A"}, ErrorInvalidFormat},
+		{[]string{"AC", "ACG"}, ErrorSizeMismatch},
+		{[]string{"A\x00"}, ErrorInvalidFormat},
+	} {
+		if _, err := NewBOSS(c.edges); err != c.want {
+			t.Errorf("NewBOSS(%q) error = %v, want %v", c.edges, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package bitvector
+
+// BPTree is an ordinal tree encoded as balanced parentheses: a depth-first
+// traversal writes a 1 on entering a node and a 0 on leaving it. Nodes are
+// numbered in preorder, so node v is entered at the v-th 1.
+type BPTree struct {
+	parens *BitVector
+}
+
+// NewBPTree makes a BPTree of the tree whose node v has the parent parents[v],
+// or -1 for the root. The children of a node are ordered by index, and the
+// tree numbers the nodes in the resulting preorder. It returns ErrorNotTree
+// unless parents describes a single tree.
+func NewBPTree(parents []int) (*BPTree, error) {
+	n := len(parents)
+	root := -1
+	children := make([][]int, n)
+	for v, p := range parents {
+		switch {
+		case p == -1 && root == -1:
+			root = v
+		case p < 0 || p >= n:
+			return nil, ErrorNotTree
+		default:
+			children[p] = append(children[p], v)
+		}
+	}
+	if n > 0 && root == -1 {
+		return nil, ErrorNotTree
+	}
+
+	b := NewBuilderWithSelectIndex(2 * n)
+	pos := 0
+	// Each entry is a node and the index of its next child to visit.
+	var stack [][2]int
+	if n > 0 {
+		stack = append(stack, [2]int{root, 0})
+		b.Set1(pos)
+		pos++
+	}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top[1] == len(children[top[0]]) {
+			stack = stack[:len(stack)-1]
+			pos++ // leave the node with a 0.
+			continue
+		}
+		child := children[top[0]][top[1]]
+		top[1]++
+		stack = append(stack, [2]int{child, 0})
+		b.Set1(pos)
+		pos++
+	}
+	if pos != 2*n {
+		return nil, ErrorNotTree // some nodes are on a cycle.
+	}
+	return &BPTree{parens: b.Build()}, nil
+}
+
+// NewBPTreeFromDegrees makes a BPTree of the tree whose i-th node in preorder
+// has degrees[i] children. It returns ErrorNotTree unless degrees describes a
+// single tree.
+func NewBPTreeFromDegrees(degrees []int) (*BPTree, error) {
+	b := NewBuilderWithSelectIndex(2 * len(degrees))
+	pos := 0
+	var stack []int // the number of children left to visit of each open node.
+	for i, d := range degrees {
+		if d < 0 || i > 0 && len(stack) == 0 {
+			return nil, ErrorNotTree
+		}
+		if len(stack) > 0 {
+			stack[len(stack)-1]--
+		}
+		b.Set1(pos)
+		pos++
+		stack = append(stack, d)
+		for len(stack) > 0 && stack[len(stack)-1] == 0 {
+			stack = stack[:len(stack)-1]
+			pos++
+		}
+	}
+	if len(stack) > 0 {
+		return nil, ErrorNotTree
+	}
+	return &BPTree{parens: b.Build()}, nil
+}
+
+// Len returns the number of nodes.
+func (t BPTree) Len() int {
+	return t.parens.Len() / 2
+}
+
+// Parens returns the balanced parentheses of the tree, with 1 as '(' and 0 as ')'.
+func (t BPTree) Parens() *BitVector {
+	return t.parens
+}
+
+// open returns the position of the 1 entering node v.
+func (t BPTree) open(v int) (int, error) {
+	if v < 0 || v >= t.Len() {
+		return 0, ErrorOutOfRange
+	}
+	return t.parens.Select1(v)
+}
+
+// bit returns whether the bit at pos is a 1, or false beyond the parentheses.
+func (t BPTree) bit(pos int) bool {
+	x, _ := t.parens.Get(pos)
+	return x
+}
+
+// Parent returns the parent of node v. It returns ErrorNotExist for the root.
+func (t BPTree) Parent(v int) (int, error) {
+	pos, err := t.open(v)
+	if err != nil {
+		return 0, err
+	}
+	// The parent is entered at the nearest unmatched 1 before pos.
+	excess := 0
+	for i := pos - 1; i >= 0; i-- {
+		if !t.bit(i) {
+			excess++
+		} else if excess == 0 {
+			return t.parens.Rank1(i)
+		} else {
+			excess--
+		}
+	}
+	return 0, ErrorNotExist
+}
+
+// FirstChild returns the first child of node v. It returns ErrorNotExist for a leaf.
+func (t BPTree) FirstChild(v int) (int, error) {
+	pos, err := t.open(v)
+	if err != nil {
+		return 0, err
+	}
+	if !t.bit(pos + 1) {
+		return 0, ErrorNotExist
+	}
+	return v + 1, nil
+}
+
+// NextSibling returns the next sibling of node v. It returns ErrorNotExist for a last child.
+func (t BPTree) NextSibling(v int) (int, error) {
+	pos, err := t.open(v)
+	if err != nil {
+		return 0, err
+	}
+	end, _ := t.parens.FindClose(pos)
+	if !t.bit(end + 1) {
+		return 0, ErrorNotExist
+	}
+	return t.parens.Rank1(end + 1)
+}
+
+// IsLeaf returns whether node v has no children.
+func (t BPTree) IsLeaf(v int) (bool, error) {
+	pos, err := t.open(v)
+	if err != nil {
+		return false, err
+	}
+	return !t.bit(pos + 1), nil
+}
+
+// SubtreeSize returns the number of nodes in the subtree of node v, including v.
+func (t BPTree) SubtreeSize(v int) (int, error) {
+	pos, err := t.open(v)
+	if err != nil {
+		return 0, err
+	}
+	end, _ := t.parens.FindClose(pos)
+	return (end - pos + 1) / 2, nil
+}
+
+// Depth returns the number of edges from the root to node v.
+func (t BPTree) Depth(v int) (int, error) {
+	pos, err := t.open(v)
+	if err != nil {
+		return 0, err
+	}
+	// The 1s before pos not matched before it enter the ancestors of v.
+	return 2*v - pos, nil
+}
@@ -0,0 +1,117 @@
+package bitvector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// randomParents returns the parent array of a random tree of n nodes.
+func randomParents(n int) []int {
+	perm := rand.Perm(n)
+	parents := make([]int, n)
+	for i, v := range perm {
+		parents[v] = -1
+		if i > 0 {
+			parents[v] = perm[rand.Intn(i)]
+		}
+	}
+	return parents
+}
+
+func TestBPTree(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 10, 500} {
+		parents := randomParents(n)
+		tree, err := NewBPTree(parents)
+		if err != nil {
+			t.Fatalf("NewBPTree(): %v", err)
+		}
+		if tree.Len() != n {
+			t.Errorf("Len() = %d, want %d", tree.Len(), n)
+		}
+
+		// Number the nodes in preorder with children by index, as NewBPTree does.
+		children := make([][]int, n)
+		root := 0
+		for v, p := range parents {
+			if p < 0 {
+				root = v
+			} else {
+				children[p] = append(children[p], v)
+			}
+		}
+		preorder := make([]int, n)
+		var order, degrees, depths []int
+		var visit func(v, depth int)
+		visit = func(v, depth int) {
+			preorder[v] = len(order)
+			order = append(order, v)
+			degrees = append(degrees, len(children[v]))
+			depths = append(depths, depth)
+			for _, c := range children[v] {
+				visit(c, depth+1)
+			}
+		}
+		if n > 0 {
+			visit(root, 0)
+		}
+
+		for v := range n {
+			u := order[v]
+			size := 1
+			for w := range n {
+				for a := parents[w]; a >= 0; a = parents[a] {
+					if a == u {
+						size++
+						break
+					}
+				}
+			}
+			if got, _ := tree.SubtreeSize(v); got != size {
+				t.Errorf("SubtreeSize(%d) = %d, want %d", v, got, size)
+			}
+			if got, _ := tree.Depth(v); got != depths[v] {
+				t.Errorf("Depth(%d) = %d, want %d", v, got, depths[v])
+			}
+			if got, err := tree.Parent(v); parents[u] < 0 && err != ErrorNotExist || parents[u] >= 0 && got != preorder[parents[u]] {
+				t.Errorf("Parent(%d) = %d, %v", v, got, err)
+			}
+			if got, err := tree.FirstChild(v); len(children[u]) == 0 && err != ErrorNotExist || len(children[u]) > 0 && got != preorder[children[u][0]] {
+				t.Errorf("FirstChild(%d) = %d, %v", v, got, err)
+			}
+			if leaf, _ := tree.IsLeaf(v); leaf != (len(children[u]) == 0) {
+				t.Errorf("IsLeaf(%d) = %v", v, leaf)
+			}
+			next := -1
+			if p := parents[u]; p >= 0 {
+				for j, c := range children[p] {
+					if c == u && j+1 < len(children[p]) {
+						next = preorder[children[p][j+1]]
+					}
+				}
+			}
+			if got, err := tree.NextSibling(v); next < 0 && err != ErrorNotExist || next >= 0 && got != next {
+				t.Errorf("NextSibling(%d) = %d, %v, want %d", v, got, err, next)
+			}
+		}
+
+		fromDegrees, err := NewBPTreeFromDegrees(degrees)
+		if err != nil || !fromDegrees.Parens().Equal(tree.Parens()) {
+			t.Errorf("NewBPTreeFromDegrees() = %v, %v, want the parentheses of NewBPTree()", fromDegrees, err)
+		}
+	}
+
+	if _, err := NewBPTree([]int{-1, 2, 1}); err != ErrorNotTree {
+		t.Errorf("NewBPTree() with a cycle error = %v, want %v", err, ErrorNotTree)
+	}
+	if _, err := NewBPTree([]int{-1, -1}); err != ErrorNotTree {
+		t.Errorf("NewBPTree() with two roots error = %v, want %v", err, ErrorNotTree)
+	}
+	for _, degrees := range [][]int{{1}, {0, 0}, {-1}} {
+		if _, err := NewBPTreeFromDegrees(degrees); err != ErrorNotTree {
+			t.Errorf("NewBPTreeFromDegrees(%v) error = %v, want %v", degrees, err, ErrorNotTree)
+		}
+	}
+	if _, err := (&BPTree{parens: NewBuilder(0).Build()}).Parent(0); err != ErrorOutOfRange {
+		t.Errorf("Parent() of an empty tree error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
@@ -0,0 +1,209 @@
+// Package bwt builds suffix arrays by SA-IS and the Burrows-Wheeler transform
+// of byte strings, and inverts the transform.
+//
+// The transform is of the text followed by a sentinel smaller than every
+// byte. The sentinel is left out of the transform and its row is returned
+// instead, as the FM-index of the bitvector package stores it.
+package bwt
+
+import "errors"
+
+// ErrorInvalidTransform indicates the transform and its sentinel row are not of any text.
+var ErrorInvalidTransform = errors.New("Invalid transform")
+
+// SuffixArray returns the start of the suffixes of text in lexicographic order.
+func SuffixArray(text []byte) []int {
+	s := make([]int, len(text))
+	for i, x := range text {
+		s[i] = int(x)
+	}
+	return sais(s, 255)
+}
+
+// sais returns the suffix array of s, whose symbols are in [0, upper], by
+// induced sorting: the leftmost suffixes of runs of smaller suffixes, LMS, are
+// sorted first, recursively on the names of their substrings if they are not
+// distinct, and the other suffixes are induced from them.
+func sais(s []int, upper int) []int {
+	n := len(s)
+	switch n {
+	case 0:
+		return nil
+	case 1:
+		return []int{0}
+	case 2:
+		if s[0] < s[1] {
+			return []int{0, 1}
+		}
+		return []int{1, 0}
+	}
+
+	// smaller tells whether each suffix is smaller than the next one.
+	smaller := make([]bool, n)
+	for i := n - 2; i >= 0; i-- {
+		smaller[i] = s[i] < s[i+1] || s[i] == s[i+1] && smaller[i+1]
+	}
+	// The buckets of each symbol hold its larger suffixes from startL, then
+	// its smaller suffixes from startS.
+	startL, startS := make([]int, upper+1), make([]int, upper+1)
+	for i, x := range s {
+		if smaller[i] {
+			if x < upper {
+				startL[x+1]++
+			}
+		} else {
+			startS[x]++
+		}
+	}
+	for x := 0; x <= upper; x++ {
+		startS[x] += startL[x]
+		if x < upper {
+			startL[x+1] += startS[x]
+		}
+	}
+
+	sa := make([]int, n)
+	bucket := make([]int, upper+1)
+	induce := func(lms []int) {
+		for i := range sa {
+			sa[i] = -1
+		}
+		copy(bucket, startS)
+		for _, i := range lms {
+			sa[bucket[s[i]]] = i
+			bucket[s[i]]++
+		}
+		copy(bucket, startL)
+		sa[bucket[s[n-1]]] = n - 1
+		bucket[s[n-1]]++
+		for _, i := range sa {
+			if i >= 1 && !smaller[i-1] {
+				sa[bucket[s[i-1]]] = i - 1
+				bucket[s[i-1]]++
+			}
+		}
+		copy(bucket, startL)
+		for j := n - 1; j >= 0; j-- {
+			if i := sa[j]; i >= 1 && smaller[i-1] {
+				bucket[s[i-1]+1]--
+				sa[bucket[s[i-1]+1]] = i - 1
+			}
+		}
+	}
+
+	name := make([]int, n) // the index of each LMS suffix among them, or -1.
+	var lms []int
+	for i := range name {
+		name[i] = -1
+		if i > 0 && !smaller[i-1] && smaller[i] {
+			name[i] = len(lms)
+			lms = append(lms, i)
+		}
+	}
+	induce(lms)
+	if len(lms) == 0 {
+		return sa
+	}
+
+	// Name the LMS substrings in sorted order, then sort the LMS suffixes by the string of their names.
+	m := len(lms)
+	sorted := make([]int, 0, m)
+	for _, i := range sa {
+		if name[i] >= 0 {
+			sorted = append(sorted, i)
+		}
+	}
+	end := func(i int) int {
+		if name[i]+1 < m {
+			return lms[name[i]+1]
+		}
+		return n
+	}
+	names := make([]int, m)
+	upper = 0
+	for j := 1; j < m; j++ {
+		l, r := sorted[j-1], sorted[j]
+		same := end(l)-l == end(r)-r
+		if same {
+			for el := end(l); l < el && s[l] == s[r]; l, r = l+1, r+1 {
+			}
+			same = l < n && r < n && s[l] == s[r] // the last LMS substring ends with the end of s.
+		}
+		if !same {
+			upper++
+		}
+		names[name[sorted[j]]] = upper
+	}
+	for j, k := range sais(names, upper) {
+		sorted[j] = lms[k]
+	}
+	induce(sorted)
+	return sa
+}
+
+// Transform returns the Burrows-Wheeler transform of text, the byte before
+// each suffix in lexicographic order, without the sentinel, and the row of
+// the sentinel, that of the whole text.
+func Transform(text []byte) ([]byte, int) {
+	n := len(text)
+	bwt := make([]byte, 0, n)
+	bwt = append(bwt, text[max(n-1, 0):]...) // the least row is the empty suffix.
+	sentinel := 0
+	for j, pos := range SuffixArray(text) {
+		if pos == 0 {
+			sentinel = j + 1
+		} else {
+			bwt = append(bwt, text[pos-1])
+		}
+	}
+	return bwt, sentinel
+}
+
+// Inverse returns the text whose transform is bwt with the sentinel at row
+// sentinel, as returned by Transform. It returns ErrorInvalidTransform if
+// there is no such text.
+func Inverse(bwt []byte, sentinel int) ([]byte, error) {
+	n := len(bwt)
+	if sentinel < 0 || sentinel > n || n > 0 && sentinel == 0 {
+		return nil, ErrorInvalidTransform
+	}
+	// at returns the byte of a row other than that of the sentinel.
+	at := func(j int) byte {
+		if j > sentinel {
+			return bwt[j-1]
+		}
+		return bwt[j]
+	}
+	// lf maps each row but that of the sentinel to the row of the suffix one byte longer.
+	var c [257]int
+	for _, x := range bwt {
+		c[int(x)+1]++
+	}
+	c[0] = 1
+	for x := 1; x <= 256; x++ {
+		c[x] += c[x-1]
+	}
+	lf := make([]int, n+1)
+	for j := range n + 1 {
+		if j == sentinel {
+			continue
+		}
+		x := at(j)
+		lf[j] = c[x]
+		c[x]++
+	}
+
+	text := make([]byte, n)
+	row := 0
+	for i := n - 1; i >= 0; i-- {
+		if row == sentinel {
+			return nil, ErrorInvalidTransform
+		}
+		text[i] = at(row)
+		row = lf[row]
+	}
+	if row != sentinel {
+		return nil, ErrorInvalidTransform
+	}
+	return text, nil
+}
@@ -0,0 +1,83 @@
+package bwt
+
+import (
+	"bytes"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func naiveSuffixArray(text []byte) []int {
+	sa := make([]int, len(text))
+	for i := range sa {
+		sa[i] = i
+	}
+	slices.SortFunc(sa, func(i, j int) int { return bytes.Compare(text[i:], text[j:]) })
+	return sa
+}
+
+func testTexts() [][]byte {
+	texts := [][]byte{nil, []byte("a"), []byte("ab"), []byte("ba"), []byte("aaaa"), []byte("banana"), []byte("mississippi"), {0, 255, 0, 255}}
+	for _, alphabet := range []int{1, 2, 4, 256} {
+		for _, n := range []int{3, 17, 100, 5000} {
+			text := make([]byte, n)
+			for i := range text {
+				text[i] = byte(rand.Intn(alphabet))
+			}
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+func TestSuffixArray(t *testing.T) {
+	for _, text := range testTexts() {
+		if got, want := SuffixArray(text), naiveSuffixArray(text); !slices.Equal(got, want) {
+			t.Errorf("SuffixArray(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	bwt, sentinel := Transform([]byte("banana"))
+	if string(bwt) != "annbaa" || sentinel != 4 {
+		t.Errorf("Transform(banana) = %q, %d, want annbaa, 4", bwt, sentinel)
+	}
+	for _, text := range testTexts() {
+		bwt, sentinel := Transform(text)
+		if got, err := Inverse(bwt, sentinel); err != nil || !bytes.Equal(got, text) {
+			t.Errorf("Inverse(Transform(%q)) = %q, %v", text, got, err)
+		}
+	}
+}
+
+func TestInverseInvalid(t *testing.T) {
+	for _, c := range []struct {
+		bwt      string
+		sentinel int
+	}{
+		{"annbaa", -1},
+		{"annbaa", 0},
+		{"annbaa", 7},
+		{"", 1},
+		{"ab", 1}, // the row of b is a cycle apart from the sentinel.
+	} {
+		if _, err := Inverse([]byte(c.bwt), c.sentinel); err != ErrorInvalidTransform {
+			t.Errorf("Inverse(%q, %d) error = %v, want ErrorInvalidTransform", c.bwt, c.sentinel, err)
+		}
+	}
+	if got, err := Inverse(nil, 0); err != nil || len(got) != 0 {
+		t.Errorf("Inverse(nil, 0) = %q, %v", got, err)
+	}
+}
+
+func BenchmarkSuffixArray(b *testing.B) {
+	text := make([]byte, 1<<20)
+	for i := range text {
+		text[i] = byte(rand.Intn(4))
+	}
+	b.ResetTimer()
+	for range b.N {
+		SuffixArray(text)
+	}
+}
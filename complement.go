@@ -0,0 +1,55 @@
+package bitvector
+
+// RankSelect is the query interface shared by bit vectors.
+type RankSelect interface {
+	// Len returns the size of the bit vector.
+	Len() int
+	// Get returns true or false, the value of the i-th bit.
+	Get(i int) (bool, error)
+	// Rank1 returns the count of 1s before the i-th bit.
+	Rank1(i int) (int, error)
+	// Rank0 returns the count of 0s before the i-th bit.
+	Rank0(i int) (int, error)
+	// Select1 returns the index of the i-th 1.
+	Select1(i int) (int, error)
+	// Select0 returns the index of the i-th 0.
+	Select0(i int) (int, error)
+}
+
+var _ RankSelect = BitVector{}
+
+// complement is a view of a bit vector with every bit negated.
+type complement struct {
+	b BitVector
+}
+
+// Complemented returns a view of the bit vector with every bit negated,
+// without copying it.
+func (b BitVector) Complemented() RankSelect {
+	return complement{b}
+}
+
+func (c complement) Len() int {
+	return c.b.Len()
+}
+
+func (c complement) Get(i int) (bool, error) {
+	x, err := c.b.Get(i)
+	return !x && err == nil, err
+}
+
+func (c complement) Rank1(i int) (int, error) {
+	return c.b.Rank0(i)
+}
+
+func (c complement) Rank0(i int) (int, error) {
+	return c.b.Rank1(i)
+}
+
+func (c complement) Select1(i int) (int, error) {
+	return c.b.Select0(i)
+}
+
+func (c complement) Select0(i int) (int, error) {
+	return c.b.Select1(i)
+}
@@ -0,0 +1,53 @@
+package bitvector
+
+import (
+	"testing"
+)
+
+func TestComplemented(t *testing.T) {
+	_, bv := random(1000)
+	c := bv.Complemented()
+	if c.Len() != bv.Len() {
+		t.Errorf("Len() = %d, want %d", c.Len(), bv.Len())
+	}
+
+	for i := 0; i <= bv.Len(); i++ {
+		r1, _ := c.Rank1(i)
+		r0, _ := c.Rank0(i)
+		w1, _ := bv.Rank0(i)
+		w0, _ := bv.Rank1(i)
+		if r1 != w1 || r0 != w0 {
+			t.Errorf("Rank1(%d), Rank0(%d) = %d, %d, want %d, %d", i, i, r1, r0, w1, w0)
+		}
+		if i == bv.Len() {
+			break
+		}
+		if got, _ := c.Get(i); got == mustGet(bv, i) {
+			t.Errorf("Get(%d) = %v, same as the parent", i, got)
+		}
+	}
+
+	zeros, _ := bv.Rank0(bv.Len())
+	for i := 0; i < zeros; i++ {
+		got, _ := c.Select1(i)
+		want, _ := bv.Select0(i)
+		if got != want {
+			t.Errorf("Select1(%d) = %d, want %d", i, got, want)
+		}
+	}
+	for i := 0; i < bv.CountOnes(); i++ {
+		got, _ := c.Select0(i)
+		want, _ := bv.Select1(i)
+		if got != want {
+			t.Errorf("Select0(%d) = %d, want %d", i, got, want)
+		}
+	}
+}
+
+func mustGet(b *BitVector, i int) bool {
+	x, err := b.Get(i)
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
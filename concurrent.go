@@ -0,0 +1,32 @@
+package bitvector
+
+import "sync/atomic"
+
+// ConcurrentBuilder is a builder of BitVector whose Set1 may be called from
+// many goroutines at once, as when marking visited nodes in a parallel traversal.
+// Build must be called after every Set1 has returned.
+type ConcurrentBuilder struct {
+	b *Builder
+}
+
+// NewConcurrentBuilder makes a new concurrent builder of BitVector of the specified size.
+func NewConcurrentBuilder(size int) *ConcurrentBuilder {
+	return &ConcurrentBuilder{b: NewBuilder(size)}
+}
+
+// Len returns the size of the bit vector.
+func (c ConcurrentBuilder) Len() int {
+	return c.b.Len()
+}
+
+// Set1 sets i-th bit in the bit vector to 1 atomically, so that concurrent
+// sets of other bits of the same word are not lost.
+func (c *ConcurrentBuilder) Set1(i int) {
+	c.b.checkNotBuilt()
+	atomic.OrUint64(&c.b.v[i/bitLength], uint64(1)<<uint(i%bitLength))
+}
+
+// Build builds a BitVector from the builder.
+func (c *ConcurrentBuilder) Build() *BitVector {
+	return c.b.Build()
+}
@@ -0,0 +1,38 @@
+package bitvector
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentBuilder(t *testing.T) {
+	const size, workers = 1000, 16
+	c := NewConcurrentBuilder(size)
+	want := NewBuilder(size)
+	positions := make([][]int, workers)
+	for w := range positions {
+		for range 500 {
+			i := rand.Intn(size)
+			positions[w] = append(positions[w], i)
+			want.Set1(i)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, ps := range positions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, i := range ps {
+				c.Set1(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, wantBV := c.Build(), want.Build()
+	if c.Len() != size || !got.Equal(wantBV) {
+		t.Errorf("ConcurrentBuilder lost updates: %d 1s, want %d", got.CountOnes(), wantBV.CountOnes())
+	}
+}
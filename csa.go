@@ -0,0 +1,62 @@
+package bitvector
+
+// CSA is a compressed suffix array of a byte string, answering the suffix
+// array and its inverse from an FMIndex of the text. Lookup walks the suffixes
+// back to a sampled position of the text, and InverseLookup walks them back
+// from the row of the next sampled position, at most sampleRate steps each.
+type CSA struct {
+	fm         *FMIndex
+	sampleRate int
+	inverse    []int // the row of every sampleRate-th position of the text.
+}
+
+// NewCSA makes a CSA of text, sampling every sampleRate-th position of the
+// text for both Lookup and InverseLookup. It panics if sampleRate is not positive.
+func NewCSA(text []byte, sampleRate int) *CSA {
+	if sampleRate <= 0 {
+		panic("bitvector: CSA sample rate must be positive")
+	}
+	sa := suffixArray(text)
+	a := &CSA{fm: newFMIndex(text, sa, sampleRate), sampleRate: sampleRate}
+	a.inverse = make([]int, (len(text)+sampleRate-1)/sampleRate)
+	for i, pos := range sa {
+		if pos%sampleRate == 0 {
+			a.inverse[pos/sampleRate] = i + 1 // row 0 is the sentinel.
+		}
+	}
+	return a
+}
+
+// Len returns the length of the text.
+func (a CSA) Len() int {
+	return a.fm.Len()
+}
+
+// FMIndex returns the FMIndex of the text, to count and locate patterns.
+func (a CSA) FMIndex() *FMIndex {
+	return a.fm
+}
+
+// Lookup returns the position in the text of the i-th suffix in lexicographic order.
+func (a CSA) Lookup(i int) (int, error) {
+	if i < 0 || i >= a.Len() {
+		return 0, ErrorOutOfRange
+	}
+	return a.fm.position(i + 1), nil
+}
+
+// InverseLookup returns the index in lexicographic order of the suffix at position pos of the text.
+func (a CSA) InverseLookup(pos int) (int, error) {
+	if pos < 0 || pos >= a.Len() {
+		return 0, ErrorOutOfRange
+	}
+	// Start from the next sampled position, or the sentinel at row 0 past the end.
+	next, row := a.Len(), 0
+	if k := (pos + a.sampleRate - 1) / a.sampleRate; k < len(a.inverse) {
+		next, row = k*a.sampleRate, a.inverse[k]
+	}
+	for ; next > pos; next-- {
+		row = a.fm.lf(row)
+	}
+	return row - 1, nil
+}
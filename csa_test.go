@@ -0,0 +1,59 @@
+package bitvector
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestCSA(t *testing.T) {
+	for _, text := range [][]byte{
+		nil,
+		[]byte("a"),
+		[]byte("banana"),
+		[]byte("mississippi"),
+		{0, 0, 1, 0, 0},
+		randomBytes(3000, 4),
+	} {
+		want := make([]int, len(text))
+		for i := range want {
+			want[i] = i
+		}
+		slices.SortFunc(want, func(i, j int) int { return bytes.Compare(text[i:], text[j:]) })
+
+		for _, sampleRate := range []int{1, 3, 64} {
+			a := NewCSA(text, sampleRate)
+			if a.Len() != len(text) {
+				t.Errorf("Len() = %d, want %d", a.Len(), len(text))
+			}
+			for i, pos := range want {
+				if got, err := a.Lookup(i); err != nil || got != pos {
+					t.Fatalf("sample rate %d: Lookup(%d) = %d, %v, want %d", sampleRate, i, got, err, pos)
+				}
+				if got, err := a.InverseLookup(pos); err != nil || got != i {
+					t.Fatalf("sample rate %d: InverseLookup(%d) = %d, %v, want %d", sampleRate, pos, got, err, i)
+				}
+			}
+			for _, i := range []int{-1, len(text)} {
+				if _, err := a.Lookup(i); err != ErrorOutOfRange {
+					t.Errorf("Lookup(%d) error = %v, want ErrorOutOfRange", i, err)
+				}
+				if _, err := a.InverseLookup(i); err != ErrorOutOfRange {
+					t.Errorf("InverseLookup(%d) error = %v, want ErrorOutOfRange", i, err)
+				}
+			}
+			if got := a.FMIndex().Count([]byte("ss")); got != len(occurrences(text, []byte("ss"))) {
+				t.Errorf("FMIndex().Count(ss) = %d", got)
+			}
+		}
+	}
+}
+
+func TestNewCSAPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "bitvector: CSA sample rate must be positive" {
+			t.Errorf("recover() = %v", r)
+		}
+	}()
+	NewCSA([]byte("a"), 0)
+}
@@ -0,0 +1,129 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// dictBlockSize is the number of keys in a block of StringDict.
+const dictBlockSize = 16
+
+// StringDict is a static dictionary mapping a set of strings to dense IDs,
+// their indices in sorted order, and back. The keys are front coded in
+// blocks of dictBlockSize: the first key of a block is stored whole, and
+// each other key as the length of its common prefix with the previous key
+// and the rest of it, with the lengths as uvarints. A bit vector over the
+// bytes marks the start of each block.
+type StringDict struct {
+	n      int
+	data   []byte
+	blocks *BitVector
+}
+
+// NewStringDict makes a StringDict of keys, which must be sorted and distinct.
+// It returns ErrorNotSorted otherwise.
+func NewStringDict(keys []string) (*StringDict, error) {
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			return nil, ErrorNotSorted
+		}
+	}
+	d := &StringDict{n: len(keys)}
+	var starts []int
+	for i, key := range keys {
+		if i%dictBlockSize == 0 {
+			starts = append(starts, len(d.data))
+			d.data = binary.AppendUvarint(d.data, uint64(len(key)))
+			d.data = append(d.data, key...)
+			continue
+		}
+		prev := keys[i-1]
+		lcp := 0
+		for lcp < len(prev) && lcp < len(key) && prev[lcp] == key[lcp] {
+			lcp++
+		}
+		d.data = binary.AppendUvarint(d.data, uint64(lcp))
+		d.data = binary.AppendUvarint(d.data, uint64(len(key)-lcp))
+		d.data = append(d.data, key[lcp:]...)
+	}
+	b := NewBuilderWithSelectIndex(len(d.data))
+	for _, pos := range starts {
+		b.Set1(pos)
+	}
+	d.blocks = b.Build()
+	return d, nil
+}
+
+// Len returns the number of keys.
+func (d StringDict) Len() int {
+	return d.n
+}
+
+// SizeInBytes returns the number of bytes used by the keys and the block index.
+func (d StringDict) SizeInBytes() int {
+	return len(d.data) + d.blocks.SizeInBytes()
+}
+
+// first returns the first key of block j, within the data, and the position after it.
+func (d StringDict) first(j int) ([]byte, int) {
+	pos, _ := d.blocks.Select1(j)
+	length, w := binary.Uvarint(d.data[pos:])
+	pos += w
+	return d.data[pos : pos+int(length)], pos + int(length)
+}
+
+// next returns the key following prev at pos and the position after it.
+func (d StringDict) next(prev []byte, pos int) ([]byte, int) {
+	lcp, w := binary.Uvarint(d.data[pos:])
+	pos += w
+	length, w := binary.Uvarint(d.data[pos:])
+	pos += w
+	key := append(prev[:lcp], d.data[pos:pos+int(length)]...)
+	return key, pos + int(length)
+}
+
+// Access returns the key of ID id.
+func (d StringDict) Access(id int) (string, error) {
+	if id < 0 || id >= d.n {
+		return "", ErrorOutOfRange
+	}
+	first, pos := d.first(id / dictBlockSize)
+	key := append([]byte(nil), first...)
+	for range id % dictBlockSize {
+		key, pos = d.next(key, pos)
+	}
+	return string(key), nil
+}
+
+// Lookup returns the ID of key, or false if key is not in the dictionary.
+func (d StringDict) Lookup(key string) (int, bool) {
+	blocks := (d.n + dictBlockSize - 1) / dictBlockSize
+	// The last block whose first key is at most key.
+	j := sort.Search(blocks, func(j int) bool {
+		first, _ := d.first(j)
+		return string(first) > key
+	}) - 1
+	if j < 0 {
+		return 0, false
+	}
+	first, pos := d.first(j)
+	cur := append([]byte(nil), first...)
+	for id := j * dictBlockSize; ; {
+		switch {
+		case string(cur) == key:
+			return id, true
+		case string(cur) > key:
+			return 0, false
+		}
+		if id++; id == d.n || id%dictBlockSize == 0 {
+			return 0, false
+		}
+		cur, pos = d.next(cur, pos)
+	}
+}
+
+// Contains returns whether key is in the dictionary.
+func (d StringDict) Contains(key string) bool {
+	_, ok := d.Lookup(key)
+	return ok
+}
@@ -0,0 +1,107 @@
+package bitvector
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// urlKeys returns n sorted distinct keys sharing long prefixes, as URLs do.
+func urlKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("https://example.com/users/%04d/posts/%08d", i/1000, i)
+	}
+	return keys
+}
+
+func TestStringDict(t *testing.T) {
+	for _, keys := range [][]string{
+		nil,
+		{""},
+		{"", "a", "ab", "abc", "b"},
+		randomKeys(1000, 12),
+		urlKeys(5000),
+	} {
+		d, err := NewStringDict(keys)
+		if err != nil {
+			t.Fatalf("NewStringDict(): %v", err)
+		}
+		if d.Len() != len(keys) {
+			t.Errorf("Len() = %d, want %d", d.Len(), len(keys))
+		}
+		for id, key := range keys {
+			if got, err := d.Access(id); err != nil || got != key {
+				t.Fatalf("Access(%d) = %q, %v, want %q", id, got, err, key)
+			}
+			if got, ok := d.Lookup(key); !ok || got != id {
+				t.Fatalf("Lookup(%q) = %d, %v, want %d", key, got, ok, id)
+			}
+		}
+		for _, key := range []string{"zzz", "abcde", "aa\x00", "https://example.com/users/0000/posts/"} {
+			if got, want := d.Contains(key), slices.Contains(keys, key); got != want {
+				t.Errorf("Contains(%q) = %v, want %v", key, got, want)
+			}
+		}
+		for _, id := range []int{-1, len(keys)} {
+			if _, err := d.Access(id); err != ErrorOutOfRange {
+				t.Errorf("Access(%d) error = %v, want ErrorOutOfRange", id, err)
+			}
+		}
+	}
+	if _, err := NewStringDict([]string{"b", "a"}); err != ErrorNotSorted {
+		t.Errorf("NewStringDict(b, a) error = %v, want ErrorNotSorted", err)
+	}
+	if _, err := NewStringDict([]string{"a", "a"}); err != ErrorNotSorted {
+		t.Errorf("NewStringDict(a, a) error = %v, want ErrorNotSorted", err)
+	}
+}
+
+func TestStringDictSize(t *testing.T) {
+	keys := urlKeys(100000)
+	raw := 0
+	for _, key := range keys {
+		raw += len(key)
+	}
+	d, _ := NewStringDict(keys)
+	if size := d.SizeInBytes(); 3*size > raw {
+		t.Errorf("SizeInBytes() = %d for %d bytes of keys", size, raw)
+	}
+}
+
+// heapGrowth returns the growth of the heap in use after calling f, keeping its result alive.
+func heapGrowth(f func() any) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	v := f()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(v)
+	return after.HeapAlloc - before.HeapAlloc
+}
+
+func BenchmarkStringDict(b *testing.B) {
+	const n = 1000000
+	dict := heapGrowth(func() any {
+		d, _ := NewStringDict(urlKeys(n))
+		return d
+	})
+	pair := heapGrowth(func() any {
+		keys := urlKeys(n)
+		ids := make(map[string]int, n)
+		for id, key := range keys {
+			ids[key] = id
+		}
+		return []any{keys, ids}
+	})
+	d, _ := NewStringDict(urlKeys(n))
+	keys := urlKeys(n)
+	b.ResetTimer()
+	for i := range b.N {
+		d.Lookup(keys[i*7919%n])
+	}
+	b.ReportMetric(float64(dict)/n, "dict-bytes/key")
+	b.ReportMetric(float64(pair)/n, "map-bytes/key")
+}
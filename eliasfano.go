@@ -0,0 +1,235 @@
+package bitvector
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+// efChunk is the number of values per chunk in the serialized form of EliasFano.
+const efChunk = 64
+
+// EliasFano is the Elias-Fano encoding of a non-decreasing sequence of integers.
+type EliasFano struct {
+	n       int        // the number of values.
+	lowBits uint       // the number of low bits stored per value.
+	low     []uint64   // the packed low bits of the values.
+	high    *BitVector // the high bits of the values in unary.
+}
+
+// NewEliasFano makes an EliasFano encoding of values, which must be non-decreasing.
+func NewEliasFano(values []uint64) (*EliasFano, error) {
+	n := len(values)
+	for i := 1; i < n; i++ {
+		if values[i-1] > values[i] {
+			return nil, ErrorNotSorted
+		}
+	}
+
+	var lowBits uint
+	if n > 0 && values[n-1] > uint64(n) {
+		lowBits = uint(bits.Len64(values[n-1]/uint64(n))) - 1
+	}
+
+	low := make([]uint64, (n*int(lowBits))/bitLength+1)
+	highSize := n + 1
+	if n > 0 {
+		highSize += int(values[n-1] >> lowBits)
+	}
+	high := NewBuilderWithSelectIndex(highSize)
+	for i, x := range values {
+		setBits(low, i*int(lowBits), lowBits, x)
+		high.Set1(int(x>>lowBits) + i)
+	}
+
+	return &EliasFano{
+		n:       n,
+		lowBits: lowBits,
+		low:     low,
+		high:    high.Build(),
+	}, nil
+}
+
+// Len returns the number of values.
+func (e EliasFano) Len() int {
+	return e.n
+}
+
+// Get returns the i-th value.
+func (e EliasFano) Get(i int) (uint64, error) {
+	if i < 0 || i >= e.n {
+		return 0, ErrorOutOfRange
+	}
+	pos, err := e.high.Select1(i)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(pos-i)<<e.lowBits | getBits(e.low, i*int(e.lowBits), e.lowBits), nil
+}
+
+// WriteTo writes the encoding to w in a chunked form that EliasFanoReader can
+// decode without loading it all into memory.
+//
+// The form is the number of values and the number of low bits, followed by
+// chunks of up to 64 values. Each chunk holds the packed low bits of its values,
+// then the count of words and the words of the unary-coded gaps between the
+// high bits of consecutive values. All fields are little-endian uint64s.
+func (e EliasFano) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var written int64
+	put := func(xs ...uint64) error {
+		written += int64(len(xs) * 8)
+		return binary.Write(bw, binary.LittleEndian, xs)
+	}
+
+	if err := put(uint64(e.n), uint64(e.lowBits)); err != nil {
+		return written, err
+	}
+
+	lowWords := (efChunk*int(e.lowBits) + bitLength - 1) / bitLength
+	low := make([]uint64, lowWords+1)
+	var high []uint64
+	prev, j, gapBits := 0, 0, 0
+	for pos, i := range e.high.PositionRankPairs() {
+		h := pos - i
+		setBits(low, j*int(e.lowBits), e.lowBits, getBits(e.low, i*int(e.lowBits), e.lowBits))
+		gapBits += h - prev
+		for len(high)*bitLength <= gapBits {
+			high = append(high, 0)
+		}
+		high[gapBits/bitLength] |= uint64(1) << uint(gapBits%bitLength)
+		gapBits++
+		prev, j = h, j+1
+
+		if j == efChunk || i == e.n-1 {
+			if err := put(low[:(j*int(e.lowBits)+bitLength-1)/bitLength]...); err != nil {
+				return written, err
+			}
+			if err := put(uint64(len(high))); err != nil {
+				return written, err
+			}
+			if err := put(high...); err != nil {
+				return written, err
+			}
+			clear(low)
+			high, j, gapBits = high[:0], 0, 0
+		}
+	}
+	return written, bw.Flush()
+}
+
+// EliasFanoReader decodes the values written by EliasFano.WriteTo one by one,
+// holding only a single chunk in memory at a time.
+type EliasFanoReader struct {
+	r       io.Reader
+	n       int      // the number of values.
+	lowBits uint     // the number of low bits stored per value.
+	i       int      // the index of the next value.
+	low     []uint64 // the low bits of the current chunk.
+	high    []uint64 // the unary-coded high gaps of the current chunk.
+	pos     int      // the next bit to read in high.
+	prev    uint64   // the high bits of the previous value.
+	err     error
+}
+
+// NewEliasFanoReader makes a reader of the values written by EliasFano.WriteTo.
+// Next stops with ErrorInvalidFormat at a chunk whose high bits are longer
+// than the values could need.
+func NewEliasFanoReader(r io.Reader) (*EliasFanoReader, error) {
+	br := bufio.NewReader(r)
+	var header [2]uint64
+	if err := binary.Read(br, binary.LittleEndian, header[:]); err != nil {
+		return nil, err
+	}
+	if header[1] >= bitLength {
+		return nil, ErrorOutOfRange
+	}
+	if header[0] > uint64(maxInt)/2 {
+		return nil, ErrorInvalidFormat
+	}
+	lowBits := uint(header[1])
+	return &EliasFanoReader{
+		r:       br,
+		n:       int(header[0]),
+		lowBits: lowBits,
+		low:     make([]uint64, (efChunk*int(lowBits)+bitLength-1)/bitLength+1),
+	}, nil
+}
+
+// Len returns the number of values.
+func (e EliasFanoReader) Len() int {
+	return e.n
+}
+
+// Next returns the next value, or false when all values have been read or
+// an error occurred.
+func (e *EliasFanoReader) Next() (uint64, bool) {
+	if e.err != nil || e.i >= e.n {
+		return 0, false
+	}
+	j := e.i % efChunk
+	if j == 0 {
+		if e.err = e.readChunk(); e.err != nil {
+			return 0, false
+		}
+	}
+
+	p := e.pos
+	for {
+		k := p / bitLength
+		if k >= len(e.high) {
+			e.err = io.ErrUnexpectedEOF
+			return 0, false
+		}
+		if x := e.high[k] >> uint(p%bitLength); x != 0 {
+			p += bits.TrailingZeros64(x)
+			break
+		}
+		p = (k + 1) * bitLength
+	}
+	e.prev += uint64(p - e.pos)
+	e.pos = p + 1
+	e.i++
+
+	return e.prev<<e.lowBits | getBits(e.low, j*int(e.lowBits), e.lowBits), true
+}
+
+// Err returns the first error that was encountered by Next.
+func (e EliasFanoReader) Err() error {
+	return e.err
+}
+
+func (e *EliasFanoReader) readChunk() error {
+	count := min(efChunk, e.n-e.i)
+	low := e.low[:(count*int(e.lowBits)+bitLength-1)/bitLength]
+	if err := binary.Read(e.r, binary.LittleEndian, low); err != nil {
+		return unexpected(err)
+	}
+	var m uint64
+	if err := binary.Read(e.r, binary.LittleEndian, &m); err != nil {
+		return unexpected(err)
+	}
+	// The high bits of the last value are less than 2n by the choice of
+	// lowBits, so the gaps left for this chunk span at most 2n-prev bits.
+	if e.prev > 2*uint64(e.n) || m > uint64(maxInt) ||
+		m > (uint64(count)+2*uint64(e.n)-e.prev)/bitLength+1 {
+		return ErrorInvalidFormat
+	}
+	if cap(e.high) < int(m) {
+		e.high = make([]uint64, m)
+	}
+	e.high = e.high[:m]
+	if err := binary.Read(e.r, binary.LittleEndian, e.high); err != nil {
+		return unexpected(err)
+	}
+	e.pos = 0
+	return nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
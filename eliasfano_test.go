@@ -0,0 +1,150 @@
+package bitvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func randomSorted(n int, max uint64) []uint64 {
+	values := make([]uint64, n)
+	for i := range values {
+		values[i] = uint64(rand.Int63n(int64(max)))
+	}
+	slices.Sort(values)
+	return values
+}
+
+func TestEliasFano(t *testing.T) {
+	for _, values := range [][]uint64{
+		nil,
+		{0},
+		{5, 5, 5},
+		randomSorted(1000, 1e9),
+		randomSorted(1000, 100),
+	} {
+		ef, err := NewEliasFano(values)
+		if err != nil {
+			t.Fatalf("NewEliasFano(): %v", err)
+		}
+		if ef.Len() != len(values) {
+			t.Errorf("Len() = %d, want %d", ef.Len(), len(values))
+		}
+		for i, want := range values {
+			if got, err := ef.Get(i); err != nil || got != want {
+				t.Errorf("Get(%d) = %d, %v, want %d", i, got, err, want)
+			}
+		}
+	}
+
+	if _, err := NewEliasFano([]uint64{2, 1}); err != ErrorNotSorted {
+		t.Errorf("NewEliasFano(unsorted) error = %v, want %v", err, ErrorNotSorted)
+	}
+}
+
+func TestEliasFanoReader(t *testing.T) {
+	for _, values := range [][]uint64{
+		nil,
+		{7},
+		randomSorted(1000, 1e12),
+		randomSorted(1000, 50),
+		append(randomSorted(100, 10), 1<<40),
+	} {
+		ef, _ := NewEliasFano(values)
+		var buf bytes.Buffer
+		n, err := ef.WriteTo(&buf)
+		if err != nil || n != int64(buf.Len()) {
+			t.Fatalf("WriteTo() = %d, %v, wrote %d bytes", n, err, buf.Len())
+		}
+
+		r, err := NewEliasFanoReader(&buf)
+		if err != nil {
+			t.Fatalf("NewEliasFanoReader(): %v", err)
+		}
+		if r.Len() != ef.Len() {
+			t.Errorf("Len() = %d, want %d", r.Len(), ef.Len())
+		}
+		for i := 0; i < ef.Len(); i++ {
+			want, _ := ef.Get(i)
+			if got, ok := r.Next(); !ok || got != want {
+				t.Fatalf("Next() #%d = %d, %v, want %d", i, got, ok, want)
+			}
+		}
+		if _, ok := r.Next(); ok {
+			t.Errorf("Next() after the last value returned true")
+		}
+		if r.Err() != nil {
+			t.Errorf("Err() = %v", r.Err())
+		}
+	}
+}
+
+func TestEliasFanoReaderCorrupt(t *testing.T) {
+	ef, _ := NewEliasFano(randomSorted(200, 1e6))
+	for _, m := range []uint64{1 << 62, 1 << 40, uint64(3 * ef.Len())} {
+		var buf bytes.Buffer
+		ef.WriteTo(&buf)
+		// Overwrite the word count of the high bits of the first chunk, after the header and its low bits.
+		data := buf.Bytes()
+		offset := 16 + 8*((efChunk*int(ef.lowBits)+bitLength-1)/bitLength)
+		binary.LittleEndian.PutUint64(data[offset:], m)
+
+		r, err := NewEliasFanoReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("NewEliasFanoReader(): %v", err)
+		}
+		if _, ok := r.Next(); ok {
+			t.Errorf("Next() with %d high words returned true", m)
+		}
+		if r.Err() != ErrorInvalidFormat {
+			t.Errorf("Err() with %d high words = %v, want %v", m, r.Err(), ErrorInvalidFormat)
+		}
+	}
+
+	header := binary.LittleEndian.AppendUint64(nil, 1<<63)
+	header = binary.LittleEndian.AppendUint64(header, 0)
+	if _, err := NewEliasFanoReader(bytes.NewReader(header)); err != ErrorInvalidFormat {
+		t.Errorf("NewEliasFanoReader() of 2^63 values error = %v, want %v", err, ErrorInvalidFormat)
+	}
+}
+
+func TestEliasFanoReaderTruncated(t *testing.T) {
+	ef, _ := NewEliasFano(randomSorted(200, 1e6))
+	var buf bytes.Buffer
+	ef.WriteTo(&buf)
+
+	r, err := NewEliasFanoReader(bytes.NewReader(buf.Bytes()[:buf.Len()/2]))
+	if err != nil {
+		t.Fatalf("NewEliasFanoReader(): %v", err)
+	}
+	for {
+		if _, ok := r.Next(); !ok {
+			break
+		}
+	}
+	if r.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("Err() = %v, want %v", r.Err(), io.ErrUnexpectedEOF)
+	}
+
+	// A first chunk whose high bits run past 2n leaves no room for the
+	// second, which must not be allocated from its word count.
+	var data []byte
+	for _, x := range []uint64{65, 0, 4, 0, 0, 0, ^uint64(0), 1 << 40} {
+		data = binary.LittleEndian.AppendUint64(data, x)
+	}
+	r, err = NewEliasFanoReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewEliasFanoReader(): %v", err)
+	}
+	for {
+		if _, ok := r.Next(); !ok {
+			break
+		}
+	}
+	if r.Err() != ErrorInvalidFormat {
+		t.Errorf("Err() after high bits past 2n = %v, want %v", r.Err(), ErrorInvalidFormat)
+	}
+}
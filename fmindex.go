@@ -0,0 +1,163 @@
+package bitvector
+
+import (
+	"cmp"
+	"slices"
+)
+
+// FMIndex is a full-text index of a byte string answering the count and the
+// positions of the occurrences of a pattern by backward search over the
+// Burrows-Wheeler transform of the text, held in a WaveletMatrix.
+//
+// The transform is of the text followed by a sentinel smaller than every byte.
+// The sentinel is stored as a 0 byte at row dollar, which Occ discounts.
+type FMIndex struct {
+	bwt     *WaveletMatrix
+	dollar  int      // the row whose transform is the sentinel.
+	c       [257]int // the count of rows starting with a byte less than each byte.
+	sampled *BitVector
+	samples []int // the position in the text of each sampled row, in the order of rows.
+}
+
+// NewFMIndex makes an FMIndex of text, keeping the position of every
+// sampleRate-th position of the text so that Locate takes at most sampleRate
+// steps per occurrence. It panics if sampleRate is not positive.
+func NewFMIndex(text []byte, sampleRate int) *FMIndex {
+	if sampleRate <= 0 {
+		panic("bitvector: FMIndex sample rate must be positive")
+	}
+	return newFMIndex(text, suffixArray(text), sampleRate)
+}
+
+// newFMIndex makes an FMIndex of text from its suffix array.
+func newFMIndex(text []byte, sa []int, sampleRate int) *FMIndex {
+	rows := len(sa) + 1
+	f := &FMIndex{}
+	bwt := make([]byte, rows)
+	sampled := NewBuilder(rows)
+	for j := range rows {
+		pos := len(text) // the sentinel is the least suffix.
+		if j > 0 {
+			pos = sa[j-1]
+		}
+		if pos == 0 {
+			f.dollar = j
+		} else {
+			bwt[j] = text[pos-1]
+		}
+		if pos%sampleRate == 0 {
+			sampled.Set1(j)
+			f.samples = append(f.samples, pos)
+		}
+	}
+	f.bwt = NewWaveletMatrix(bwt)
+	f.sampled = sampled.Build()
+
+	f.c[0] = 1
+	for _, x := range text {
+		f.c[int(x)+1]++
+	}
+	for x := 1; x <= 256; x++ {
+		f.c[x] += f.c[x-1]
+	}
+	return f
+}
+
+// suffixArray returns the start of the suffixes of text in lexicographic
+// order, computed by prefix doubling.
+func suffixArray(text []byte) []int {
+	n := len(text)
+	sa := make([]int, n)
+	rank := make([]int, n)
+	for i, x := range text {
+		sa[i], rank[i] = i, int(x)
+	}
+	next := make([]int, n)
+	for k := 1; n > 1; k *= 2 {
+		key := func(i int) (int, int) {
+			if i+k < n {
+				return rank[i], rank[i+k]
+			}
+			return rank[i], -1
+		}
+		compare := func(i, j int) int {
+			ri, si := key(i)
+			rj, sj := key(j)
+			return cmp.Or(cmp.Compare(ri, rj), cmp.Compare(si, sj))
+		}
+		slices.SortFunc(sa, compare)
+		next[sa[0]] = 0
+		for j := 1; j < n; j++ {
+			next[sa[j]] = next[sa[j-1]]
+			if compare(sa[j-1], sa[j]) < 0 {
+				next[sa[j]]++
+			}
+		}
+		rank, next = next, rank
+		if rank[sa[n-1]] == n-1 || k >= n {
+			break
+		}
+	}
+	return sa
+}
+
+// Len returns the length of the text.
+func (f FMIndex) Len() int {
+	return f.bwt.Len() - 1
+}
+
+// occ returns the count of byte x in the transform before row j.
+func (f FMIndex) occ(x byte, j int) int {
+	count, _ := f.bwt.Rank(x, j)
+	if x == 0 && f.dollar < j {
+		count--
+	}
+	return count
+}
+
+// rows returns the range of rows starting with pattern.
+func (f FMIndex) rows(pattern []byte) (int, int) {
+	if len(pattern) == 0 {
+		return 1, f.bwt.Len() // every row but that of the sentinel.
+	}
+	s, e := 0, f.bwt.Len()
+	for i := len(pattern) - 1; i >= 0 && s < e; i-- {
+		x := pattern[i]
+		s, e = f.c[x]+f.occ(x, s), f.c[x]+f.occ(x, e)
+	}
+	return s, max(s, e)
+}
+
+// Count returns the number of occurrences of pattern in the text.
+func (f FMIndex) Count(pattern []byte) int {
+	s, e := f.rows(pattern)
+	return e - s
+}
+
+// Locate returns the positions of the occurrences of pattern in the text in ascending order.
+func (f FMIndex) Locate(pattern []byte) []int {
+	s, e := f.rows(pattern)
+	positions := make([]int, 0, e-s)
+	for j := s; j < e; j++ {
+		positions = append(positions, f.position(j))
+	}
+	slices.Sort(positions)
+	return positions
+}
+
+// position returns the position in the text of the suffix at row j.
+func (f FMIndex) position(j int) int {
+	for steps := 0; ; steps++ {
+		if x, _ := f.sampled.Get(j); x {
+			rank, _ := f.sampled.Rank1(j)
+			return f.samples[rank] + steps
+		}
+		j = f.lf(j)
+	}
+}
+
+// lf returns the row of the suffix starting one byte earlier than that at row j, which must not be dollar.
+func (f FMIndex) lf(j int) int {
+	x, _ := f.bwt.Access(j)
+	return f.c[x] + f.occ(x, j)
+}
@@ -0,0 +1,63 @@
+package bitvector
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestFMIndex(t *testing.T) {
+	for _, text := range [][]byte{
+		nil,
+		[]byte("abracadabra"),
+		[]byte("mississippi"),
+		{0, 0, 1, 0, 0},
+		randomBytes(2000, 3),
+	} {
+		for _, sampleRate := range []int{1, 4, 32} {
+			f := NewFMIndex(text, sampleRate)
+			if f.Len() != len(text) {
+				t.Errorf("Len() = %d, want %d", f.Len(), len(text))
+			}
+			patterns := [][]byte{nil, []byte("a"), []byte("abra"), []byte("ss"), {0}, {0, 0}, {9}}
+			for i := 0; i+4 <= len(text); i += 97 {
+				patterns = append(patterns, text[i:i+4])
+			}
+			for _, pattern := range patterns {
+				want := occurrences(text, pattern)
+				if got := f.Count(pattern); got != len(want) {
+					t.Errorf("Count(%q) = %d, want %d", pattern, got, len(want))
+				}
+				if got := f.Locate(pattern); !slices.Equal(got, want) {
+					t.Errorf("sample rate %d: Locate(%q) = %v, want %v", sampleRate, pattern, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestSuffixArray(t *testing.T) {
+	text := randomBytes(1000, 2)
+	want := make([]int, len(text))
+	for i := range want {
+		want[i] = i
+	}
+	slices.SortFunc(want, func(i, j int) int { return bytes.Compare(text[i:], text[j:]) })
+	if got := suffixArray(text); !slices.Equal(got, want) {
+		t.Errorf("suffixArray() = %v, want %v", got, want)
+	}
+}
+
+// occurrences returns the positions of pattern in text, which may overlap.
+func occurrences(text, pattern []byte) []int {
+	positions := []int{}
+	for i := 0; i+len(pattern) <= len(text); i++ {
+		if len(pattern) == 0 && i == len(text) {
+			break
+		}
+		if bytes.Equal(text[i:i+len(pattern)], pattern) {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
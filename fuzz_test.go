@@ -0,0 +1,137 @@
+package bitvector
+
+import "testing"
+
+func FuzzRankSelect(f *testing.F) {
+	for _, size := range []int{0, 1, 63, 64, 65, 127, 128, 511, 512, 513, 1024} {
+		data := make([]byte, (size+7)/8)
+		for i := range data {
+			data[i] = byte(i*37 + size)
+		}
+		f.Add(data, size)
+	}
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 65)
+
+	f.Fuzz(func(t *testing.T, data []byte, size int) {
+		if size < 0 || size > 8*len(data)+bitLength {
+			return
+		}
+		bits := make([]bool, size)
+		for i := range min(size, 8*len(data)) {
+			bits[i] = data[i/8]>>uint(i%8)&1 == 1
+		}
+		builds := map[string]func(*Builder) *BitVector{
+			"Build":                       (*Builder).Build,
+			"BuildWithSelectIndex(true)":  func(b *Builder) *BitVector { return b.BuildWithSelectIndex(true) },
+			"BuildWithSelectIndex(false)": func(b *Builder) *BitVector { return b.BuildWithSelectIndex(false) },
+			"BuildSIMDFriendly":           (*Builder).BuildSIMDFriendly,
+			"BuildRank9":                  (*Builder).BuildRank9,
+			"BuildInterleaved":            (*Builder).BuildInterleaved,
+		}
+		for name, build := range builds {
+			bv := build(NewBuilderFromBytes(data, size, LSBFirst))
+			if bv.Len() != size {
+				t.Fatalf("%s: Len() = %d, want %d", name, bv.Len(), size)
+			}
+
+			ones := 0
+			for i := 0; i <= size; i++ {
+				r1, err := bv.Rank1(i)
+				r0, _ := bv.Rank0(i)
+				if err != nil || r1 != ones || r0+r1 != i {
+					t.Fatalf("%s: Rank1(%d), Rank0(%d) = %d, %d, %v, want %d, %d", name, i, i, r1, r0, err, ones, i-ones)
+				}
+				if i == size {
+					break
+				}
+				got, err := bv.Get(i)
+				if err != nil || got != bits[i] {
+					t.Fatalf("%s: Get(%d) = %v, %v, want %v", name, i, got, err, bits[i])
+				}
+				if bits[i] {
+					if pos, err := bv.Select1(ones); err != nil || pos != i {
+						t.Fatalf("%s: Select1(Rank1(%d)) = %d, %v", name, i, pos, err)
+					}
+					ones++
+				} else if pos, err := bv.Select0(i - ones); err != nil || pos != i {
+					t.Fatalf("%s: Select0(Rank0(%d)) = %d, %v", name, i, pos, err)
+				}
+			}
+			if bv.CountOnes() != ones {
+				t.Fatalf("%s: CountOnes() = %d, want %d", name, bv.CountOnes(), ones)
+			}
+
+			if _, err := bv.Get(size); err != ErrorOutOfRange {
+				t.Fatalf("%s: Get(%d) error = %v, want %v", name, size, err, ErrorOutOfRange)
+			}
+			if _, err := bv.Get(-1); err != ErrorOutOfRange {
+				t.Fatalf("%s: Get(-1) error = %v, want %v", name, err, ErrorOutOfRange)
+			}
+			if _, err := bv.Rank1(-1); err != ErrorOutOfRange {
+				t.Fatalf("%s: Rank1(-1) error = %v, want %v", name, err, ErrorOutOfRange)
+			}
+			if _, err := bv.Rank1(size + 1); err != ErrorOutOfRange {
+				t.Fatalf("%s: Rank1(%d) error = %v, want %v", name, size+1, err, ErrorOutOfRange)
+			}
+			if _, err := bv.Select1(ones); err != ErrorNotExist {
+				t.Fatalf("%s: Select1(%d) error = %v, want %v", name, ones, err, ErrorNotExist)
+			}
+			if _, err := bv.Select0(size - ones); err != ErrorNotExist {
+				t.Fatalf("%s: Select0(%d) error = %v, want %v", name, size-ones, err, ErrorNotExist)
+			}
+		}
+	})
+}
+
+func FuzzUnmarshalBinary(f *testing.F) {
+	for _, size := range []int{0, 1, 64, 600, 1500} {
+		builds := []func(*Builder) *BitVector{
+			(*Builder).Build,
+			func(b *Builder) *BitVector { return b.BuildWithSelectIndex(true) },
+			(*Builder).BuildSIMDFriendly,
+			(*Builder).BuildRank9,
+			(*Builder).BuildInterleaved,
+		}
+		for j, build := range builds {
+			b := NewBuilder(size)
+			for i := 0; i < size; i += 1 + (i*7+j)%5 {
+				b.Set1(i)
+			}
+			data, _ := build(b).MarshalBinary()
+			f.Add(data)
+		}
+	}
+	sparse := NewBuilder(2000)
+	sparse.Set1(3)
+	sparse.Set1(1999)
+	data, _ := sparse.BuildWithSelectIndex(true).MarshalBinary()
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var bv BitVector
+		// A constant bit vector may be of any size without words to check.
+		if err := bv.UnmarshalBinary(data); err != nil || bv.Len() > 8*len(data) {
+			return
+		}
+		ones := 0
+		for i := 0; i <= bv.Len(); i++ {
+			if r, err := bv.Rank1(i); err != nil || r != ones {
+				t.Fatalf("Rank1(%d) = %d, %v, want %d", i, r, err, ones)
+			}
+			if i == bv.Len() {
+				break
+			}
+			if x, _ := bv.Get(i); x {
+				if pos, err := bv.Select1(ones); err != nil || pos != i {
+					t.Fatalf("Select1(%d) = %d, %v, want %d", ones, pos, err, i)
+				}
+				ones++
+			} else if pos, err := bv.Select0(i - ones); err != nil || pos != i {
+				t.Fatalf("Select0(%d) = %d, %v, want %d", i-ones, pos, err, i)
+			}
+		}
+		if bv.CountOnes() != ones {
+			t.Fatalf("CountOnes() = %d, want %d", bv.CountOnes(), ones)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package bitvector
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// goldenVectors returns the vectors stored in testdata/*.bin by name.
+// The files must never be regenerated: they pin the binary form of old releases.
+func goldenVectors() map[string]*BitVector {
+	empty := NewBuilder(0)
+
+	dense := NewBuilder(1000)
+	for i := 0; i < 1000; i++ {
+		if i%3 != 0 {
+			dense.Set1(i)
+		}
+	}
+
+	indexed := NewBuilder(3000)
+	for i := 0; i < 3000; i++ {
+		if i%7 < 4 {
+			indexed.Set1(i)
+		}
+	}
+
+	sparse := NewBuilder(100000)
+	for _, i := range []int{7, 4096, 77777, 99999} {
+		sparse.Set1(i)
+	}
+
+	return map[string]*BitVector{
+		"empty":   empty.Build(),
+		"dense":   dense.Build(),
+		"indexed": indexed.BuildWithSelectIndex(true),
+		"sparse":  sparse.Build(),
+	}
+}
+
+// loadGolden reads the golden fixture testdata/name.bin.
+func loadGolden(t *testing.T, name string) *BitVector {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name+".bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b BitVector
+	if err := b.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary(%s): %v", name, err)
+	}
+	return &b
+}
+
+func TestGolden(t *testing.T) {
+	for name, want := range goldenVectors() {
+		got := loadGolden(t, name)
+		if got.Len() != want.Len() {
+			t.Errorf("%s: Len() = %d, want %d", name, got.Len(), want.Len())
+		}
+		if !slices.Equal(got.Positions(), want.Positions()) {
+			t.Errorf("%s: Positions() differ", name)
+		}
+		if got.HasSelectIndex() != want.HasSelectIndex() {
+			t.Errorf("%s: HasSelectIndex() = %v, want %v", name, got.HasSelectIndex(), want.HasSelectIndex())
+		}
+		sameQueries(t, got, want)
+	}
+}
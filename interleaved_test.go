@@ -0,0 +1,44 @@
+package bitvector
+
+import (
+	"slices"
+	"testing"
+	"unsafe"
+)
+
+func TestBuildInterleaved(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 447, 448, 449, 5000} {
+		s, want := random(size)
+		b := NewBuilder(size)
+		for i, c := range s {
+			b.Set(i, c == '1')
+		}
+		bv := b.BuildInterleaved()
+		sameQueries(t, bv, want)
+		if !slices.Equal(bv.BlockRanks(), want.BlockRanks()) {
+			t.Errorf("size %d: BlockRanks() differ", size)
+		}
+		if want.constant {
+			continue
+		}
+		store := bv.store.(lineStore)
+		if p := uintptr(unsafe.Pointer(&store.v[0])); p%cacheLineSize != 0 {
+			t.Errorf("size %d: lines start at %#x, not aligned to a cache line", size, p)
+		}
+
+		data, _ := bv.MarshalBinary()
+		got, err := decode(data, false)
+		if err != nil {
+			t.Fatalf("size %d: decode() error = %v", size, err)
+		}
+		if _, ok := got.store.(lineStore); !ok {
+			t.Errorf("size %d: decode() lost the interleaved layout", size)
+		}
+		sameQueries(t, got, want)
+
+		store.v[0]++ // a corrupt count
+		if bv.SanitizeTail(); bv.CountOnes() != want.CountOnes() {
+			t.Errorf("size %d: CountOnes() after SanitizeTail = %d, want %d", size, bv.CountOnes(), want.CountOnes())
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package bitvector
+
+import (
+	"cmp"
+	"slices"
+)
+
+// K2Tree is an n×n matrix of bits stored as a quadtree with k = 2, taking
+// space proportional to the number of 1s and how they cluster. The matrix is
+// padded to a power of two and split into four quadrants, marked by a 1 if
+// they hold a 1, and so on recursively down to the cells. The bits of all the
+// nodes are laid out level by level, the cells last, so that the children of
+// the node marked at position x start at 4*Rank1(x+1).
+type K2Tree struct {
+	n     int
+	side  int        // the padded side of the matrix.
+	bits  *BitVector // the bits of the quadrants larger than a cell, then those of the cells.
+	inner int        // the count of the bits of the quadrants larger than a cell.
+}
+
+// NewK2Tree makes a K2Tree of an n×n matrix whose 1s are cells, given as
+// [row, column] pairs in any order. It returns ErrorOutOfRange if a cell is
+// out of the matrix.
+func NewK2Tree(n int, cells [][2]int) (*K2Tree, error) {
+	for _, cell := range cells {
+		if cell[0] < 0 || cell[0] >= n || cell[1] < 0 || cell[1] >= n {
+			return nil, ErrorOutOfRange
+		}
+	}
+	t := &K2Tree{n: n, side: 2}
+	for t.side < n {
+		t.side *= 2
+	}
+
+	// Each node is a square of the matrix and the cells it holds.
+	type node struct {
+		row, col int
+		cells    [][2]int
+	}
+	var bits []bool
+	nodes := []node{{0, 0, cells}}
+	for size := t.side / 2; size >= 1; size /= 2 {
+		if size == 1 {
+			t.inner = len(bits)
+		}
+		var next []node
+		for _, v := range nodes {
+			var quadrants [4][][2]int
+			for _, cell := range v.cells {
+				q := (cell[0]-v.row)/size*2 + (cell[1]-v.col)/size
+				quadrants[q] = append(quadrants[q], cell)
+			}
+			for q, cells := range quadrants {
+				bits = append(bits, len(cells) > 0)
+				if len(cells) > 0 {
+					next = append(next, node{v.row + q/2*size, v.col + q%2*size, cells})
+				}
+			}
+		}
+		nodes = next
+	}
+
+	b := NewBuilder(len(bits))
+	for i, x := range bits {
+		b.Set(i, x)
+	}
+	t.bits = b.Build()
+	return t, nil
+}
+
+// Size returns n, the number of rows and columns.
+func (t K2Tree) Size() int {
+	return t.n
+}
+
+// SizeInBytes returns the number of bytes used by the bits and their rank index.
+func (t K2Tree) SizeInBytes() int {
+	return t.bits.SizeInBytes()
+}
+
+// CountOnes returns the count of 1s in the matrix.
+func (t K2Tree) CountOnes() int {
+	inner, _ := t.bits.Rank1(t.inner)
+	return t.bits.CountOnes() - inner
+}
+
+// report appends the 1s within rows [r1, r2) and columns [c1, c2) of the
+// node whose children start at position first and whose square of the
+// specified size starts at row and col.
+func (t K2Tree) report(first, size, row, col, r1, r2, c1, c2 int, cells [][2]int) [][2]int {
+	size /= 2
+	for q := range 4 {
+		r, c := row+q/2*size, col+q%2*size
+		if r >= r2 || r+size <= r1 || c >= c2 || c+size <= c1 {
+			continue
+		}
+		pos := first + q
+		if x, _ := t.bits.Get(pos); !x {
+			continue
+		}
+		if pos >= t.inner {
+			cells = append(cells, [2]int{r, c})
+			continue
+		}
+		children, _ := t.bits.Rank1(pos + 1)
+		cells = t.report(4*children, size, r, c, r1, r2, c1, c2, cells)
+	}
+	return cells
+}
+
+// Get returns true or false, the bit at row r and column c.
+func (t K2Tree) Get(r, c int) (bool, error) {
+	if r < 0 || r >= t.n || c < 0 || c >= t.n {
+		return false, ErrorOutOfRange
+	}
+	return len(t.report(0, t.side, 0, 0, r, r+1, c, c+1, nil)) > 0, nil
+}
+
+// Row returns the columns of the 1s in row r in ascending order, the
+// successors of node r if the matrix is the adjacency matrix of a graph.
+func (t K2Tree) Row(r int) ([]int, error) {
+	if r < 0 || r >= t.n {
+		return nil, ErrorOutOfRange
+	}
+	var cols []int
+	for _, cell := range t.report(0, t.side, 0, 0, r, r+1, 0, t.n, nil) {
+		cols = append(cols, cell[1])
+	}
+	return cols, nil
+}
+
+// Column returns the rows of the 1s in column c in ascending order, the
+// predecessors of node c if the matrix is the adjacency matrix of a graph.
+func (t K2Tree) Column(c int) ([]int, error) {
+	if c < 0 || c >= t.n {
+		return nil, ErrorOutOfRange
+	}
+	var rows []int
+	for _, cell := range t.report(0, t.side, 0, 0, 0, t.n, c, c+1, nil) {
+		rows = append(rows, cell[0])
+	}
+	return rows, nil
+}
+
+// Range returns the [row, column] pairs of the 1s within rows [r1, r2) and
+// columns [c1, c2) in row-major order. It returns ErrorOutOfRange if the
+// ranges are out of the matrix.
+func (t K2Tree) Range(r1, r2, c1, c2 int) ([][2]int, error) {
+	if r1 < 0 || r1 > r2 || r2 > t.n || c1 < 0 || c1 > c2 || c2 > t.n {
+		return nil, ErrorOutOfRange
+	}
+	cells := t.report(0, t.side, 0, 0, r1, r2, c1, c2, nil)
+	slices.SortFunc(cells, func(a, b [2]int) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+	return cells, nil
+}
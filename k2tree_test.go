@@ -0,0 +1,114 @@
+package bitvector
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestK2Tree(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 17, 100} {
+		for _, density := range []float64{0, 0.01, 0.3} {
+			m := NewMatrixBuilder(n, n)
+			var cells [][2]int
+			for r := range n {
+				for c := range n {
+					if rand.Float64() < density {
+						m.Set(r, c, true)
+						cells = append(cells, [2]int{r, c})
+					}
+				}
+			}
+			want := m.Build()
+			rand.Shuffle(len(cells), func(i, j int) { cells[i], cells[j] = cells[j], cells[i] })
+			tree, err := NewK2Tree(n, cells)
+			if err != nil {
+				t.Fatalf("NewK2Tree(): %v", err)
+			}
+			if tree.Size() != n || tree.CountOnes() != want.CountOnes() {
+				t.Errorf("Size(), CountOnes() = %d, %d, want %d, %d", tree.Size(), tree.CountOnes(), n, want.CountOnes())
+			}
+			for r := range n {
+				var cols []int
+				for c := range n {
+					got, err := tree.Get(r, c)
+					x, _ := want.Get(r, c)
+					if err != nil || got != x {
+						t.Fatalf("n=%d: Get(%d, %d) = %v, %v, want %v", n, r, c, got, err, x)
+					}
+					if x {
+						cols = append(cols, c)
+					}
+				}
+				if got, _ := tree.Row(r); !slices.Equal(got, cols) {
+					t.Errorf("Row(%d) = %v, want %v", r, got, cols)
+				}
+			}
+			for c := range n {
+				var rows []int
+				for r := range n {
+					if x, _ := want.Get(r, c); x {
+						rows = append(rows, r)
+					}
+				}
+				if got, _ := tree.Column(c); !slices.Equal(got, rows) {
+					t.Errorf("Column(%d) = %v, want %v", c, got, rows)
+				}
+			}
+			for range 50 {
+				r1, r2 := rand.Intn(n+1), rand.Intn(n+1)
+				c1, c2 := rand.Intn(n+1), rand.Intn(n+1)
+				r1, r2 = min(r1, r2), max(r1, r2)
+				c1, c2 = min(c1, c2), max(c1, c2)
+				var cells [][2]int
+				for r := r1; r < r2; r++ {
+					for c := c1; c < c2; c++ {
+						if x, _ := want.Get(r, c); x {
+							cells = append(cells, [2]int{r, c})
+						}
+					}
+				}
+				if got, err := tree.Range(r1, r2, c1, c2); err != nil || !slices.Equal(got, cells) {
+					t.Errorf("Range(%d, %d, %d, %d) = %v, %v, want %v", r1, r2, c1, c2, got, err, cells)
+				}
+			}
+		}
+	}
+}
+
+func TestK2TreeOutOfRange(t *testing.T) {
+	if _, err := NewK2Tree(3, [][2]int{{0, 3}}); err != ErrorOutOfRange {
+		t.Errorf("NewK2Tree() error = %v, want ErrorOutOfRange", err)
+	}
+	tree, _ := NewK2Tree(3, [][2]int{{1, 2}})
+	if _, err := tree.Get(3, 0); err != ErrorOutOfRange {
+		t.Errorf("Get(3, 0) error = %v, want ErrorOutOfRange", err)
+	}
+	if _, err := tree.Row(-1); err != ErrorOutOfRange {
+		t.Errorf("Row(-1) error = %v, want ErrorOutOfRange", err)
+	}
+	if _, err := tree.Column(3); err != ErrorOutOfRange {
+		t.Errorf("Column(3) error = %v, want ErrorOutOfRange", err)
+	}
+	if _, err := tree.Range(0, 4, 0, 1); err != ErrorOutOfRange {
+		t.Errorf("Range(0, 4, 0, 1) error = %v, want ErrorOutOfRange", err)
+	}
+}
+
+func TestK2TreeSparse(t *testing.T) {
+	const n = 1 << 20
+	var cells [][2]int
+	for range 1000 {
+		r := rand.Intn(n)
+		cells = append(cells, [2]int{r, (r + rand.Intn(100)) % n})
+	}
+	tree, _ := NewK2Tree(n, cells)
+	if size := tree.SizeInBytes(); size > 20*len(cells) {
+		t.Errorf("SizeInBytes() = %d for %d cells", size, len(cells))
+	}
+	for _, cell := range cells {
+		if x, _ := tree.Get(cell[0], cell[1]); !x {
+			t.Errorf("Get(%d, %d) = false", cell[0], cell[1])
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package bitvector
+
+// LCP is the longest common prefix array of a text in 2n bits, answering the
+// length of the common prefix of each suffix and the previous one in
+// lexicographic order through the CSA of the text.
+//
+// The lengths are kept in the order of the text, where the length at
+// position p, PLCP[p], is at least PLCP[p-1]-1, so PLCP[p]+2p increases and
+// is marked by the p-th 1 of a bit vector of 2n bits.
+type LCP struct {
+	csa  *CSA
+	plcp *BitVector
+}
+
+// NewLCP makes the LCP of text, looking up its suffixes in a, which must be a CSA of text.
+// It returns ErrorSizeMismatch if a is not of the length of text.
+func NewLCP(text []byte, a *CSA) (*LCP, error) {
+	n := len(text)
+	if a.Len() != n {
+		return nil, ErrorSizeMismatch
+	}
+	// prev is the position of the previous suffix of that at each position, or -1.
+	prev := make([]int, n)
+	sa := suffixArray(text)
+	for i, pos := range sa {
+		prev[pos] = -1
+		if i > 0 {
+			prev[pos] = sa[i-1]
+		}
+	}
+	b := NewBuilderWithSelectIndex(2 * n)
+	l := 0
+	for p, q := range prev {
+		if q < 0 {
+			l = 0
+		} else {
+			for p+l < n && q+l < n && text[p+l] == text[q+l] {
+				l++
+			}
+		}
+		b.Set1(l + 2*p)
+		l = max(l-1, 0)
+	}
+	return &LCP{csa: a, plcp: b.Build()}, nil
+}
+
+// Len returns the length of the text.
+func (c LCP) Len() int {
+	return c.csa.Len()
+}
+
+// SizeInBytes returns the number of bytes used by the lengths, not counting the CSA.
+func (c LCP) SizeInBytes() int {
+	return c.plcp.SizeInBytes()
+}
+
+// LCP returns the length of the longest common prefix of the i-th suffix in
+// lexicographic order and the previous one, or 0 for the first suffix.
+func (c LCP) LCP(i int) (int, error) {
+	pos, err := c.csa.Lookup(i)
+	if err != nil {
+		return 0, err
+	}
+	return c.PLCP(pos)
+}
+
+// PLCP returns the length of the longest common prefix of the suffix at
+// position pos of the text and the previous one in lexicographic order.
+func (c LCP) PLCP(pos int) (int, error) {
+	if pos < 0 || pos >= c.Len() {
+		return 0, ErrorOutOfRange
+	}
+	x, _ := c.plcp.Select1(pos)
+	return x - 2*pos, nil
+}
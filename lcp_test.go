@@ -0,0 +1,61 @@
+package bitvector
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestLCP(t *testing.T) {
+	for _, text := range [][]byte{
+		nil,
+		[]byte("a"),
+		[]byte("aaaaaa"),
+		[]byte("banana"),
+		[]byte("mississippi"),
+		randomBytes(3000, 2),
+		randomBytes(3000, 26),
+	} {
+		sa := make([]int, len(text))
+		for i := range sa {
+			sa[i] = i
+		}
+		slices.SortFunc(sa, func(i, j int) int { return bytes.Compare(text[i:], text[j:]) })
+
+		a := NewCSA(text, 8)
+		c, err := NewLCP(text, a)
+		if err != nil {
+			t.Fatalf("NewLCP(): %v", err)
+		}
+		if c.Len() != len(text) {
+			t.Errorf("Len() = %d, want %d", c.Len(), len(text))
+		}
+		if size := c.SizeInBytes(); len(text) > 1000 && size > len(text) {
+			t.Errorf("SizeInBytes() = %d for %d bytes of text", size, len(text))
+		}
+		for i, pos := range sa {
+			want := 0
+			if i > 0 {
+				for q := sa[i-1]; pos+want < len(text) && q+want < len(text) && text[pos+want] == text[q+want]; want++ {
+				}
+			}
+			if got, err := c.LCP(i); err != nil || got != want {
+				t.Fatalf("%q: LCP(%d) = %d, %v, want %d", text[:min(len(text), 20)], i, got, err, want)
+			}
+			if got, _ := c.PLCP(pos); got != want {
+				t.Fatalf("PLCP(%d) = %d, want %d", pos, got, want)
+			}
+		}
+		for _, i := range []int{-1, len(text)} {
+			if _, err := c.LCP(i); err != ErrorOutOfRange {
+				t.Errorf("LCP(%d) error = %v, want ErrorOutOfRange", i, err)
+			}
+			if _, err := c.PLCP(i); err != ErrorOutOfRange {
+				t.Errorf("PLCP(%d) error = %v, want ErrorOutOfRange", i, err)
+			}
+		}
+	}
+	if _, err := NewLCP([]byte("ab"), NewCSA([]byte("abc"), 1)); err != ErrorSizeMismatch {
+		t.Errorf("NewLCP() of another CSA error = %v, want ErrorSizeMismatch", err)
+	}
+}
@@ -0,0 +1,102 @@
+package bitvector
+
+import "sort"
+
+// LOUDSTrie is a trie of a set of keys encoded by its level-order unary degree
+// sequence: visiting the nodes breadth first, each writes a 1 per child then a
+// 0, after 10 for a parent of the root. Node x is the x-th node visited,
+// pointed to by the x-th 1, and its children follow the x-th 0.
+type LOUDSTrie struct {
+	louds    *BitVector
+	labels   []byte     // the byte leading to each node but the root, in the order of nodes.
+	terminal *BitVector // whether each node ends a key.
+}
+
+// NewLOUDSTrie makes a LOUDSTrie of keys, which must be sorted and distinct.
+// It returns ErrorNotSorted otherwise.
+func NewLOUDSTrie(keys []string) (*LOUDSTrie, error) {
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			return nil, ErrorNotSorted
+		}
+	}
+
+	// Each node is the range of keys sharing its prefix of length depth.
+	type node struct{ lo, hi, depth int }
+	louds := []bool{true, false}
+	var labels []byte
+	var terminal []bool
+	for queue := []node{{0, len(keys), 0}}; len(queue) > 0; queue = queue[1:] {
+		n := queue[0]
+		lo := n.lo
+		if lo < n.hi && len(keys[lo]) == n.depth {
+			lo++
+		}
+		terminal = append(terminal, lo > n.lo)
+		for lo < n.hi {
+			label := keys[lo][n.depth]
+			hi := lo + sort.Search(n.hi-lo, func(i int) bool { return keys[lo+i][n.depth] > label })
+			louds = append(louds, true)
+			labels = append(labels, label)
+			queue = append(queue, node{lo, hi, n.depth + 1})
+			lo = hi
+		}
+		louds = append(louds, false)
+	}
+
+	b := NewBuilderWithSelectIndex(len(louds))
+	for i, x := range louds {
+		b.Set(i, x)
+	}
+	t := NewBuilder(len(terminal))
+	for i, x := range terminal {
+		t.Set(i, x)
+	}
+	return &LOUDSTrie{louds: b.Build(), labels: labels, terminal: t.Build()}, nil
+}
+
+// Len returns the number of keys.
+func (t LOUDSTrie) Len() int {
+	return t.terminal.CountOnes()
+}
+
+// SizeInBytes returns the number of bytes used by the trie.
+func (t LOUDSTrie) SizeInBytes() int {
+	return t.louds.SizeInBytes() + len(t.labels) + t.terminal.SizeInBytes()
+}
+
+// child returns the child of node x by label, or false if there is none.
+func (t LOUDSTrie) child(x int, label byte) (int, bool) {
+	start, _ := t.louds.Select0(x)
+	end, _ := t.louds.Select0(x + 1)
+	first, _ := t.louds.Rank1(start + 1)
+	children := t.labels[first-1 : first-1+end-start-1]
+	j := sort.Search(len(children), func(j int) bool { return children[j] >= label })
+	if j == len(children) || children[j] != label {
+		return 0, false
+	}
+	return first + j, true
+}
+
+// Lookup returns the ID of key, which is its index among the keys in the
+// breadth-first order of the trie, or false if key is not in the trie.
+func (t LOUDSTrie) Lookup(key string) (int, bool) {
+	x := 0
+	for i := 0; i < len(key); i++ {
+		var ok bool
+		if x, ok = t.child(x, key[i]); !ok {
+			return 0, false
+		}
+	}
+	if ok, _ := t.terminal.Get(x); !ok {
+		return 0, false
+	}
+	id, _ := t.terminal.Rank1(x)
+	return id, true
+}
+
+// Contains returns whether key is in the trie.
+func (t LOUDSTrie) Contains(key string) bool {
+	_, ok := t.Lookup(key)
+	return ok
+}
@@ -0,0 +1,62 @@
+package bitvector
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// randomKeys returns n sorted distinct keys of up to maxLen bytes from a small alphabet.
+func randomKeys(n, maxLen int) []string {
+	set := map[string]bool{}
+	for len(set) < n {
+		key := make([]byte, rand.Intn(maxLen+1))
+		for i := range key {
+			key[i] = "abcd"[rand.Intn(4)]
+		}
+		set[string(key)] = true
+	}
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestLOUDSTrie(t *testing.T) {
+	for _, keys := range [][]string{
+		nil,
+		{""},
+		{"a", "ab", "abc", "b", "ba"},
+		randomKeys(1000, 10),
+	} {
+		trie, err := NewLOUDSTrie(keys)
+		if err != nil {
+			t.Fatalf("NewLOUDSTrie(): %v", err)
+		}
+		if trie.Len() != len(keys) {
+			t.Errorf("Len() = %d, want %d", trie.Len(), len(keys))
+		}
+		ids := map[int]bool{}
+		for _, key := range keys {
+			id, ok := trie.Lookup(key)
+			if !ok || id < 0 || id >= len(keys) || ids[id] {
+				t.Fatalf("Lookup(%q) = %d, %v, want a new ID below %d", key, id, ok, len(keys))
+			}
+			ids[id] = true
+			for _, other := range []string{key + "e", key + "a", key[:len(key)/2]} {
+				if want := slices.Contains(keys, other); trie.Contains(other) != want {
+					t.Errorf("Contains(%q) = %v, want %v", other, !want, want)
+				}
+			}
+		}
+	}
+
+	if _, err := NewLOUDSTrie([]string{"b", "a"}); err != ErrorNotSorted {
+		t.Errorf("NewLOUDSTrie() of unsorted keys error = %v, want %v", err, ErrorNotSorted)
+	}
+	if _, err := NewLOUDSTrie([]string{"a", "a"}); err != ErrorNotSorted {
+		t.Errorf("NewLOUDSTrie() of repeated keys error = %v, want %v", err, ErrorNotSorted)
+	}
+}
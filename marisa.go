@@ -0,0 +1,224 @@
+package bitvector
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// marisaLevels is the number of nested tries of MarisaTrie, counting the outer one.
+const marisaLevels = 3
+
+// MarisaTrie is a trie of a set of keys in the manner of marisa-trie: a
+// LOUDSTrie whose edges may spell more than one byte, with the first byte
+// as the label of the edge and the rest, its tail, kept in a nested
+// MarisaTrie of the reversed tails. Each linked node keeps the node of its
+// reversed tail, from which the tail is read by walking up the nested trie.
+// The tails of the innermost trie are kept as they are, one after another.
+type MarisaTrie struct {
+	trie   LOUDSTrie
+	linked *BitVector         // whether the edge to each node has a tail.
+	links  *PackedArray[uint] // the node of the tail of each linked node, or its start in tails.
+	next   *MarisaTrie        // the trie of the reversed tails, or nil for the innermost trie.
+	tails  []byte
+	ends   *BitVector // marks the last byte of each tail in tails.
+}
+
+// NewMarisaTrie makes a MarisaTrie of keys, which must be sorted and distinct.
+// It returns ErrorNotSorted otherwise.
+func NewMarisaTrie(keys []string) (*MarisaTrie, error) {
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			return nil, ErrorNotSorted
+		}
+	}
+	return newMarisaTrie(keys, marisaLevels), nil
+}
+
+// newMarisaTrie makes a MarisaTrie of sorted distinct keys with the specified number of nested tries.
+func newMarisaTrie(keys []string, levels int) *MarisaTrie {
+	// Each node is the range of keys sharing its prefix of length depth.
+	type node struct{ lo, hi, depth int }
+	louds := []bool{true, false}
+	var labels []byte
+	var terminal, linked []bool
+	var tails []string
+	for queue := []node{{0, len(keys), 0}}; len(queue) > 0; queue = queue[1:] {
+		n := queue[0]
+		lo := n.lo
+		if lo < n.hi && len(keys[lo]) == n.depth {
+			lo++
+		}
+		terminal = append(terminal, lo > n.lo)
+		for lo < n.hi {
+			label := keys[lo][n.depth]
+			hi := lo + sort.Search(n.hi-lo, func(i int) bool { return keys[lo+i][n.depth] > label })
+			// The edge spells the common prefix of the keys of the child.
+			depth := n.depth + 1
+			for first, last := keys[lo], keys[hi-1]; depth < len(first) && first[depth] == last[depth]; depth++ {
+			}
+			louds = append(louds, true)
+			labels = append(labels, label)
+			linked = append(linked, depth > n.depth+1)
+			if depth > n.depth+1 {
+				tails = append(tails, keys[lo][n.depth+1:depth])
+			}
+			queue = append(queue, node{lo, hi, depth})
+			lo = hi
+		}
+		louds = append(louds, false)
+	}
+
+	b := NewBuilderWithSelectIndex(len(louds))
+	for i, x := range louds {
+		b.Set(i, x)
+	}
+	t := NewBuilderWithSelectIndex(len(terminal))
+	l := NewBuilder(len(terminal))
+	for i, x := range terminal {
+		t.Set(i, x)
+		if i > 0 {
+			l.Set(i, linked[i-1])
+		}
+	}
+	m := &MarisaTrie{
+		trie:   LOUDSTrie{louds: b.Build(), labels: labels, terminal: t.Build()},
+		linked: l.Build(),
+	}
+
+	links := make([]int, len(tails))
+	if levels > 1 && len(tails) > 0 {
+		reversed := make([]string, len(tails))
+		for i, tail := range tails {
+			reversed[i] = reverseString(tail)
+		}
+		distinct := slices.Compact(slices.Sorted(slices.Values(reversed)))
+		m.next = newMarisaTrie(distinct, levels-1)
+		for i, key := range reversed {
+			links[i], _ = m.next.node(key)
+		}
+	} else {
+		// Keep each distinct tail once.
+		starts := map[string]int{}
+		var ends []int
+		for i, tail := range tails {
+			start, ok := starts[tail]
+			if !ok {
+				start = len(m.tails)
+				starts[tail] = start
+				m.tails = append(m.tails, tail...)
+				ends = append(ends, len(m.tails)-1)
+			}
+			links[i] = start
+		}
+		e := NewBuilderWithSelectIndex(len(m.tails))
+		for _, pos := range ends {
+			e.Set1(pos)
+		}
+		m.ends = e.Build()
+	}
+	m.links = packInts(links, slices.Max(append(links, 0)))
+	return m
+}
+
+// reverseString returns s with its bytes in reverse order.
+func reverseString(s string) string {
+	b := []byte(s)
+	slices.Reverse(b)
+	return string(b)
+}
+
+// Len returns the number of keys.
+func (m MarisaTrie) Len() int {
+	return m.trie.Len()
+}
+
+// SizeInBytes returns the number of bytes used by the trie and the nested tries.
+func (m MarisaTrie) SizeInBytes() int {
+	size := m.trie.SizeInBytes() + m.linked.SizeInBytes() + (m.links.Len()*m.links.Width()+7)/8
+	if m.next != nil {
+		return size + m.next.SizeInBytes()
+	}
+	return size + len(m.tails) + m.ends.SizeInBytes()
+}
+
+// tail returns the tail of the edge to node x, which must be linked.
+func (m MarisaTrie) tail(x int) []byte {
+	j, _ := m.linked.Rank1(x)
+	link := int(m.links.Get(j))
+	if m.next != nil {
+		return m.next.up(link)
+	}
+	end, _ := m.ends.Rank1(link)
+	end, _ = m.ends.Select1(end)
+	return m.tails[link : end+1]
+}
+
+// up returns the bytes of the edges from node x up to the root, which are
+// those of the key of x in reverse order.
+func (m MarisaTrie) up(x int) []byte {
+	var key []byte
+	for x > 0 {
+		if linked, _ := m.linked.Get(x); linked {
+			tail := m.tail(x)
+			for i := len(tail) - 1; i >= 0; i-- {
+				key = append(key, tail[i])
+			}
+		}
+		key = append(key, m.trie.labels[x-1])
+		// The parent of x has the list of children holding the x-th 1.
+		pos, _ := m.trie.louds.Select1(x)
+		x, _ = m.trie.louds.Rank0(pos)
+		x--
+	}
+	return key
+}
+
+// node returns the node spelling key, or false if there is none.
+func (m MarisaTrie) node(key string) (int, bool) {
+	x := 0
+	for i := 0; i < len(key); {
+		var ok bool
+		if x, ok = m.trie.child(x, key[i]); !ok {
+			return 0, false
+		}
+		i++
+		if linked, _ := m.linked.Get(x); linked {
+			tail := m.tail(x)
+			if !strings.HasPrefix(key[i:], string(tail)) {
+				return 0, false
+			}
+			i += len(tail)
+		}
+	}
+	return x, true
+}
+
+// Lookup returns the ID of key, which is its index among the keys in the
+// breadth-first order of the trie, or false if key is not in the trie.
+func (m MarisaTrie) Lookup(key string) (int, bool) {
+	x, ok := m.node(key)
+	if !ok {
+		return 0, false
+	}
+	if ok, _ := m.trie.terminal.Get(x); !ok {
+		return 0, false
+	}
+	id, _ := m.trie.terminal.Rank1(x)
+	return id, true
+}
+
+// Contains returns whether key is in the trie.
+func (m MarisaTrie) Contains(key string) bool {
+	_, ok := m.Lookup(key)
+	return ok
+}
+
+// Access returns the key of ID id.
+func (m MarisaTrie) Access(id int) (string, error) {
+	x, err := m.trie.terminal.Select1(id)
+	if err != nil {
+		return "", ErrorOutOfRange
+	}
+	return reverseString(string(m.up(x))), nil
+}
@@ -0,0 +1,85 @@
+package bitvector
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestMarisaTrie(t *testing.T) {
+	for _, keys := range [][]string{
+		nil,
+		{""},
+		{"", "a", "ab", "abc", "abd", "b"},
+		{"apple", "application", "apply", "banana", "band", "bandana"},
+		randomKeys(2000, 20),
+		urlKeys(3000),
+		wordURLs(3000),
+	} {
+		m, err := NewMarisaTrie(keys)
+		if err != nil {
+			t.Fatalf("NewMarisaTrie(): %v", err)
+		}
+		if m.Len() != len(keys) {
+			t.Errorf("Len() = %d, want %d", m.Len(), len(keys))
+		}
+		ids := map[int]bool{}
+		for _, key := range keys {
+			id, ok := m.Lookup(key)
+			if !ok || id < 0 || id >= len(keys) || ids[id] {
+				t.Fatalf("Lookup(%q) = %d, %v", key, id, ok)
+			}
+			ids[id] = true
+			if got, err := m.Access(id); err != nil || got != key {
+				t.Fatalf("Access(%d) = %q, %v, want %q", id, got, err, key)
+			}
+		}
+		for _, key := range []string{"ap", "applications", "bandanas", "c", "https://example.com/users/"} {
+			if got, want := m.Contains(key), slices.Contains(keys, key); got != want {
+				t.Errorf("Contains(%q) = %v, want %v", key, got, want)
+			}
+		}
+		for _, id := range []int{-1, len(keys)} {
+			if _, err := m.Access(id); err != ErrorOutOfRange {
+				t.Errorf("Access(%d) error = %v, want ErrorOutOfRange", id, err)
+			}
+		}
+	}
+	if _, err := NewMarisaTrie([]string{"b", "a"}); err != ErrorNotSorted {
+		t.Errorf("NewMarisaTrie(b, a) error = %v, want ErrorNotSorted", err)
+	}
+}
+
+// wordURLs returns n sorted distinct URLs made of random words, whose tails
+// are long and alike, unlike those of urlKeys.
+func wordURLs(n int) []string {
+	word := func() string {
+		b := make([]byte, 3+rand.Intn(10))
+		for i := range b {
+			b[i] = "etaoinshrdlucmfw"[rand.Intn(16)]
+		}
+		return string(b)
+	}
+	set := map[string]bool{}
+	for len(set) < n {
+		set["https://"+word()+".example.com/"+word()+"/"+word()] = true
+	}
+	keys := slices.Collect(maps.Keys(set))
+	slices.Sort(keys)
+	return keys
+}
+
+func TestMarisaTrieSize(t *testing.T) {
+	keys := wordURLs(20000)
+	m, _ := NewMarisaTrie(keys)
+	flat, _ := NewLOUDSTrie(keys)
+	if size := m.SizeInBytes(); 4*size > 3*flat.SizeInBytes() {
+		t.Errorf("SizeInBytes() = %d, want less than 3/4 of the %d of a LOUDSTrie", size, flat.SizeInBytes())
+	}
+	for _, key := range keys {
+		if !m.Contains(key) {
+			t.Fatalf("Contains(%q) = false", key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package bitvector
+
+// BitMatrix is a matrix of bits stored row-major in a BitVector.
+type BitMatrix struct {
+	rows, cols int
+	b          *BitVector
+}
+
+// MatrixBuilder is a builder of BitMatrix.
+type MatrixBuilder struct {
+	rows, cols int
+	b          *Builder
+}
+
+// NewMatrixBuilder makes a new builder of BitMatrix of the specified shape.
+// It panics if rows*cols overflows.
+func NewMatrixBuilder(rows, cols int) *MatrixBuilder {
+	if rows < 0 || cols < 0 || cols > 0 && rows > maxSize/cols {
+		panic("bitvector: BitMatrix size out of range")
+	}
+	return &MatrixBuilder{
+		rows: rows,
+		cols: cols,
+		b:    NewBuilder(rows * cols),
+	}
+}
+
+// Set sets the bit at row r and column c to v.
+// It panics if r or c is out of range, as Builder.Set does.
+func (m *MatrixBuilder) Set(r, c int, v bool) {
+	if r < 0 || r >= m.rows || c < 0 || c >= m.cols {
+		panic("bitvector: BitMatrix index out of range")
+	}
+	m.b.Set(r*m.cols+c, v)
+}
+
+// Build builds a BitMatrix from the builder.
+func (m *MatrixBuilder) Build() *BitMatrix {
+	return &BitMatrix{
+		rows: m.rows,
+		cols: m.cols,
+		b:    m.b.Build(),
+	}
+}
+
+// Rows returns the number of rows.
+func (m BitMatrix) Rows() int {
+	return m.rows
+}
+
+// Cols returns the number of columns.
+func (m BitMatrix) Cols() int {
+	return m.cols
+}
+
+// Get returns true or false, the bit at row r and column c.
+func (m BitMatrix) Get(r, c int) (bool, error) {
+	if r < 0 || r >= m.rows || c < 0 || c >= m.cols {
+		return false, ErrorOutOfRange
+	}
+	return m.b.Get(r*m.cols + c)
+}
+
+// RankRow returns the count of 1s in row r before column c.
+func (m BitMatrix) RankRow(r, c int) (int, error) {
+	if r < 0 || r >= m.rows || c < 0 || c > m.cols {
+		return 0, ErrorOutOfRange
+	}
+	return m.b.RankRange(r*m.cols, r*m.cols+c)
+}
+
+// CountRow returns the count of 1s in row r.
+func (m BitMatrix) CountRow(r int) (int, error) {
+	return m.RankRow(r, m.cols)
+}
+
+// CountOnes returns the count of 1s in the matrix.
+func (m BitMatrix) CountOnes() int {
+	return m.b.CountOnes()
+}
@@ -0,0 +1,77 @@
+package bitvector
+
+import (
+	"testing"
+)
+
+func TestBitMatrix(t *testing.T) {
+	grid := []string{
+		"10010",
+		"00000",
+		"11111",
+		"01100",
+	}
+	b := NewMatrixBuilder(len(grid), len(grid[0]))
+	ones := 0
+	for r, row := range grid {
+		for c, x := range row {
+			b.Set(r, c, x == '1')
+			if x == '1' {
+				ones++
+			}
+		}
+	}
+	m := b.Build()
+	if m.Rows() != 4 || m.Cols() != 5 {
+		t.Errorf("Rows(), Cols() = %d, %d, want 4, 5", m.Rows(), m.Cols())
+	}
+	if m.CountOnes() != ones {
+		t.Errorf("CountOnes() = %d, want %d", m.CountOnes(), ones)
+	}
+
+	for r, row := range grid {
+		count := 0
+		for c := 0; c <= len(row); c++ {
+			if got, err := m.RankRow(r, c); err != nil || got != count {
+				t.Errorf("RankRow(%d, %d) = %d, %v, want %d", r, c, got, err, count)
+			}
+			if c == len(row) {
+				break
+			}
+			if got, _ := m.Get(r, c); got != (row[c] == '1') {
+				t.Errorf("Get(%d, %d) = %v", r, c, got)
+			}
+			if row[c] == '1' {
+				count++
+			}
+		}
+		if got, _ := m.CountRow(r); got != count {
+			t.Errorf("CountRow(%d) = %d, want %d", r, got, count)
+		}
+	}
+
+	if _, err := m.Get(0, 5); err != ErrorOutOfRange {
+		t.Errorf("Get(0, 5) error = %v, want %v", err, ErrorOutOfRange)
+	}
+	if _, err := m.CountRow(4); err != ErrorOutOfRange {
+		t.Errorf("CountRow(4) error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
+
+func TestMatrixBuilderSetOutOfRange(t *testing.T) {
+	for _, cell := range [][2]int{{0, 3}, {1, -1}, {2, 0}, {-1, 0}} {
+		b := NewMatrixBuilder(2, 3)
+		func() {
+			defer func() {
+				if r := recover(); r != "bitvector: BitMatrix index out of range" {
+					t.Errorf("Set(%d, %d) recovered %v", cell[0], cell[1], r)
+				}
+			}()
+			b.Set(cell[0], cell[1], true)
+		}()
+		// The column overflow must not reach the next row.
+		if m := b.Build(); m.CountOnes() != 0 {
+			t.Errorf("Set(%d, %d) set %d bits", cell[0], cell[1], m.CountOnes())
+		}
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package bitvector
+
+import (
+	"os"
+)
+
+// OpenFile reads a file written from MarshalBinary and returns the BitVector
+// together with a function to release it. Memory mapping is not supported on
+// this platform, so the file is loaded into memory.
+func OpenFile(path string) (*BitVector, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := decode(data, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, func() error { return nil }, nil
+}
@@ -0,0 +1,39 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package bitvector
+
+import (
+	"os"
+	"syscall"
+)
+
+// OpenFile memory-maps a file written from MarshalBinary and returns a read-only
+// BitVector that refers to the mapped file instead of loading it into memory,
+// together with a function that unmaps it. The BitVector must not be used
+// after the file is unmapped.
+func OpenFile(path string) (*BitVector, func() error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() == 0 {
+		return nil, nil, ErrorInvalidFormat
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := decode(data, true)
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, nil, err
+	}
+	return b, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,134 @@
+package bitvector
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// And returns the intersection of a and b as a new bit vector.
+func And(a, b *BitVector) (*BitVector, error) {
+	if a.size != b.size {
+		return nil, ErrorSizeMismatch
+	}
+	return combine(a, b, func(x, y uint64) uint64 { return x & y }), nil
+}
+
+// Or returns the union of a and b as a new bit vector.
+func Or(a, b *BitVector) (*BitVector, error) {
+	if a.size != b.size {
+		return nil, ErrorSizeMismatch
+	}
+	return combine(a, b, func(x, y uint64) uint64 { return x | y }), nil
+}
+
+// Xor returns the symmetric difference of a and b as a new bit vector.
+func Xor(a, b *BitVector) (*BitVector, error) {
+	if a.size != b.size {
+		return nil, ErrorSizeMismatch
+	}
+	return combine(a, b, func(x, y uint64) uint64 { return x ^ y }), nil
+}
+
+// AndPadded returns the intersection of a and b as a new bit vector of the larger
+// of their sizes, treating the shorter one as padded with 0s.
+func AndPadded(a, b *BitVector) *BitVector {
+	return combine(a, b, func(x, y uint64) uint64 { return x & y })
+}
+
+// OrPadded returns the union of a and b as a new bit vector of the larger
+// of their sizes, treating the shorter one as padded with 0s.
+func OrPadded(a, b *BitVector) *BitVector {
+	return combine(a, b, func(x, y uint64) uint64 { return x | y })
+}
+
+// combine returns the bit vector of op applied to each pair of words of a and b,
+// treating the shorter one as padded with 0s.
+func combine(a, b *BitVector, op func(x, y uint64) uint64) *BitVector {
+	c := NewBuilder(max(a.size, b.size))
+	for i := range c.v {
+		c.v[i] = op(a.word(i), b.word(i))
+	}
+	c.clearTail()
+	return c.Build()
+}
+
+// SymmetricDifferenceCount returns the count of bits that differ between a and b.
+func SymmetricDifferenceCount(a, b *BitVector) (int, error) {
+	if a.size != b.size {
+		return 0, ErrorSizeMismatch
+	}
+	count := 0
+	for i := range a.numWords() {
+		count += bits.OnesCount64(a.word(i) ^ b.word(i))
+	}
+	return count, nil
+}
+
+// DiffWords returns the count of bits that differ between a and b in each word,
+// treating the shorter one as padded with 0s, to locate where they differ.
+func DiffWords(a, b *BitVector) []int {
+	diff := make([]int, max(a.numWords(), b.numWords()))
+	for k := range diff {
+		diff[k] = bits.OnesCount64(a.word(k) ^ b.word(k))
+	}
+	return diff
+}
+
+// Jaccard returns the Jaccard similarity of a and b, the count of 1s in both
+// divided by the count of 1s in either. It is 1 if neither has a 1.
+func Jaccard(a, b *BitVector) (float64, error) {
+	if a.size != b.size {
+		return 0, ErrorSizeMismatch
+	}
+	intersection, union := 0, 0
+	for i := range a.numWords() {
+		x, y := a.word(i), b.word(i)
+		intersection += bits.OnesCount64(x & y)
+		union += bits.OnesCount64(x | y)
+	}
+	if union == 0 {
+		return 1, nil
+	}
+	return float64(intersection) / float64(union), nil
+}
+
+// JaccardMatrix returns the matrix of the Jaccard similarities of every pair of vs,
+// evaluating each pair once.
+func JaccardMatrix(vs []*BitVector) ([][]float64, error) {
+	m := make([][]float64, len(vs))
+	for i := range m {
+		m[i] = make([]float64, len(vs))
+	}
+	for i := range vs {
+		m[i][i] = 1
+		for j := i + 1; j < len(vs); j++ {
+			s, err := Jaccard(vs[i], vs[j])
+			if err != nil {
+				return nil, err
+			}
+			m[i][j], m[j][i] = s, s
+		}
+	}
+	return m, nil
+}
+
+// IntersectIterator returns an iterator over the indices of the bits set in
+// both a and b, in ascending order, without materializing the intersection.
+// It panics if the sizes of a and b differ.
+func IntersectIterator(a, b *BitVector) iter.Seq[int] {
+	if a.size != b.size {
+		panic("bitvector: IntersectIterator of different sizes")
+	}
+	return func(yield func(int) bool) {
+		for i := range a.numWords() {
+			x := a.word(i) & b.word(i)
+			for x != 0 {
+				pos := i*bitLength + bits.TrailingZeros64(x)
+				if pos >= a.size || !yield(pos) {
+					return
+				}
+				x &= x - 1
+			}
+		}
+	}
+}
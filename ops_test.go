@@ -0,0 +1,230 @@
+package bitvector
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAnd(t *testing.T) {
+	sa, a := random(1000)
+	sb, b := random(1000)
+
+	c, err := And(a, b)
+	if err != nil {
+		t.Fatalf("And(): %v", err)
+	}
+	for i := 0; i < c.Len(); i++ {
+		if got, _ := c.Get(i); got != (sa[i] == '1' && sb[i] == '1') {
+			t.Errorf("And().Get(%d) = %v", i, got)
+		}
+	}
+
+	_, short := random(999)
+	if _, err := And(a, short); err != ErrorSizeMismatch {
+		t.Errorf("And() of different sizes error = %v, want %v", err, ErrorSizeMismatch)
+	}
+}
+
+func TestOr(t *testing.T) {
+	sa, a := random(1000)
+	sb, b := random(1000)
+
+	c, err := Or(a, b)
+	if err != nil {
+		t.Fatalf("Or(): %v", err)
+	}
+	for i := 0; i < c.Len(); i++ {
+		if got, _ := c.Get(i); got != (sa[i] == '1' || sb[i] == '1') {
+			t.Errorf("Or().Get(%d) = %v", i, got)
+		}
+	}
+
+	_, short := random(999)
+	if _, err := Or(a, short); err != ErrorSizeMismatch {
+		t.Errorf("Or() of different sizes error = %v, want %v", err, ErrorSizeMismatch)
+	}
+}
+
+func TestPadded(t *testing.T) {
+	sa, a := random(100)
+	sb, b := random(150)
+	// Stray bits beyond the size of the shorter vector must not leak into the result.
+	a.store.(sliceStore)[1] |= 1 << 40
+
+	for _, c := range []struct {
+		name string
+		bv   *BitVector
+		op   func(x, y bool) bool
+	}{
+		{"OrPadded(a, b)", OrPadded(a, b), func(x, y bool) bool { return x || y }},
+		{"OrPadded(b, a)", OrPadded(b, a), func(x, y bool) bool { return x || y }},
+		{"AndPadded(a, b)", AndPadded(a, b), func(x, y bool) bool { return x && y }},
+		{"AndPadded(b, a)", AndPadded(b, a), func(x, y bool) bool { return x && y }},
+	} {
+		if c.bv.Len() != 150 {
+			t.Errorf("%s.Len() = %d, want 150", c.name, c.bv.Len())
+		}
+		ones := 0
+		for i := 0; i < 150; i++ {
+			want := c.op(i < 100 && sa[i] == '1', sb[i] == '1')
+			if got, _ := c.bv.Get(i); got != want {
+				t.Errorf("%s.Get(%d) = %v, want %v", c.name, i, got, want)
+			}
+			if want {
+				ones++
+			}
+		}
+		if c.bv.CountOnes() != ones {
+			t.Errorf("%s.CountOnes() = %d, want %d", c.name, c.bv.CountOnes(), ones)
+		}
+	}
+}
+
+func TestIntersectIterator(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 1000} {
+		_, a := random(size)
+		_, b := random(size)
+		c, _ := And(a, b)
+		if got := slices.Collect(IntersectIterator(a, b)); !slices.Equal(got, c.Positions()) {
+			t.Errorf("IntersectIterator() of size %d = %v, want %v", size, got, c.Positions())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("IntersectIterator() of different sizes did not panic")
+		}
+	}()
+	_, a := random(10)
+	_, b := random(11)
+	IntersectIterator(a, b)
+}
+
+func TestXor(t *testing.T) {
+	sa, a := random(1000)
+	sb, b := random(1000)
+
+	c, err := Xor(a, b)
+	if err != nil {
+		t.Fatalf("Xor(): %v", err)
+	}
+	for i := 0; i < c.Len(); i++ {
+		if got, _ := c.Get(i); got != (sa[i] != sb[i]) {
+			t.Errorf("Xor().Get(%d) = %v", i, got)
+		}
+	}
+	if count, _ := SymmetricDifferenceCount(a, b); c.CountOnes() != count {
+		t.Errorf("Xor().CountOnes() = %d, SymmetricDifferenceCount() = %d", c.CountOnes(), count)
+	}
+
+	if c, _ := Xor(a, a); c.CountOnes() != 0 || len(c.Positions()) != 0 {
+		t.Errorf("Xor(a, a) has %d 1s", c.CountOnes())
+	}
+
+	_, short := random(999)
+	if _, err := Xor(a, short); err != ErrorSizeMismatch {
+		t.Errorf("Xor() of different sizes error = %v, want %v", err, ErrorSizeMismatch)
+	}
+	if _, err := SymmetricDifferenceCount(a, short); err != ErrorSizeMismatch {
+		t.Errorf("SymmetricDifferenceCount() of different sizes error = %v, want %v", err, ErrorSizeMismatch)
+	}
+}
+
+func TestXorMasksTail(t *testing.T) {
+	a, b := NewBuilder(10), NewBuilder(10)
+	// Bits beyond the size may be left over in the last word.
+	a.v[0] |= 1 << 20
+	av, bv := a.Build(), b.Build()
+	c, _ := Xor(av, bv)
+	if x := c.word(0); x != 0 || c.CountOnes() != 0 {
+		t.Errorf("Xor() kept padding bits %#x", x)
+	}
+	if count, _ := SymmetricDifferenceCount(av, bv); count != 0 {
+		t.Errorf("SymmetricDifferenceCount() counted padding bits: %d", count)
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	sa, a := random(1000)
+	sb, b := random(1000)
+	intersection, union := 0, 0
+	for i := range sa {
+		if sa[i] == '1' && sb[i] == '1' {
+			intersection++
+		}
+		if sa[i] == '1' || sb[i] == '1' {
+			union++
+		}
+	}
+	if got, _ := Jaccard(a, b); got != float64(intersection)/float64(union) {
+		t.Errorf("Jaccard() = %g, want %d/%d", got, intersection, union)
+	}
+	if got, _ := Jaccard(a, a); got != 1 {
+		t.Errorf("Jaccard(a, a) = %g, want 1", got)
+	}
+	empty := NewBuilder(1000).Build()
+	if got, _ := Jaccard(empty, empty); got != 1 {
+		t.Errorf("Jaccard() of empty vectors = %g, want 1", got)
+	}
+
+	_, short := random(999)
+	if _, err := Jaccard(a, short); err != ErrorSizeMismatch {
+		t.Errorf("Jaccard() of different sizes error = %v, want %v", err, ErrorSizeMismatch)
+	}
+}
+
+func TestJaccardMatrix(t *testing.T) {
+	var vs []*BitVector
+	for range 5 {
+		_, bv := random(1000)
+		vs = append(vs, bv)
+	}
+	m, err := JaccardMatrix(vs)
+	if err != nil {
+		t.Fatalf("JaccardMatrix(): %v", err)
+	}
+	for i := range vs {
+		for j := range vs {
+			if want, _ := Jaccard(vs[i], vs[j]); m[i][j] != want {
+				t.Errorf("JaccardMatrix()[%d][%d] = %g, want %g", i, j, m[i][j], want)
+			}
+		}
+	}
+
+	_, short := random(999)
+	if _, err := JaccardMatrix(append(vs, short)); err != ErrorSizeMismatch {
+		t.Errorf("JaccardMatrix() of different sizes error = %v, want %v", err, ErrorSizeMismatch)
+	}
+}
+
+func BenchmarkJaccardMatrix(b *testing.B) {
+	var vs []*BitVector
+	for range 50 {
+		_, bv := random(100000)
+		vs = append(vs, bv)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		JaccardMatrix(vs)
+	}
+}
+
+func TestDiffWords(t *testing.T) {
+	s, a := random(1000)
+	b := NewBuilder(1000)
+	for i, c := range s {
+		b.Set(i, c == '1')
+	}
+	for _, i := range []int{130, 135, 191} {
+		b.Set(i, s[i] == '0')
+	}
+	diff := DiffWords(a, b.Build())
+	if len(diff) != 1000/bitLength+1 {
+		t.Fatalf("len(DiffWords()) = %d", len(diff))
+	}
+	for k, n := range diff {
+		if want := map[bool]int{true: 3}[k == 2]; n != want {
+			t.Errorf("DiffWords()[%d] = %d, want %d", k, n, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package bitvector
+
+import "unsafe"
+
+// Unsigned is a constraint that permits any unsigned integer type.
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// PackedArray is an array of unsigned integers stored in a fixed number of bits each.
+type PackedArray[T Unsigned] struct {
+	n     int      // the number of elements.
+	width int      // the number of bits per element.
+	b     *Builder // the packed bits.
+}
+
+// NewPackedArray makes a new packed array of n elements of width bits each.
+// It panics if width is not in [0, 64] or wider than T, or n*width overflows.
+func NewPackedArray[T Unsigned](n, width int) *PackedArray[T] {
+	var zero T
+	if width < 0 || width > bitLength || width > 8*int(unsafe.Sizeof(zero)) {
+		panic("bitvector: PackedArray width out of range")
+	}
+	if n < 0 || width > 0 && n > maxSize/width {
+		panic("bitvector: PackedArray size out of range")
+	}
+	return &PackedArray[T]{
+		n:     n,
+		width: width,
+		b:     NewBuilder(n * width),
+	}
+}
+
+// Len returns the number of elements.
+func (p PackedArray[T]) Len() int {
+	return p.n
+}
+
+// Width returns the number of bits per element.
+func (p PackedArray[T]) Width() int {
+	return p.width
+}
+
+// Get returns the i-th element.
+func (p PackedArray[T]) Get(i int) T {
+	if i < 0 || i >= p.n {
+		panic("bitvector: PackedArray index out of range")
+	}
+	return T(p.b.GetBits(i*p.width, p.width))
+}
+
+// Set sets the i-th element to the lowest Width bits of v.
+func (p *PackedArray[T]) Set(i int, v T) {
+	if i < 0 || i >= p.n {
+		panic("bitvector: PackedArray index out of range")
+	}
+	p.b.SetBits(i*p.width, p.width, uint64(v))
+}
@@ -0,0 +1,64 @@
+package bitvector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func testPackedArray[T Unsigned](t *testing.T, width int) {
+	const n = 300
+	p := NewPackedArray[T](n, width)
+	if p.Len() != n || p.Width() != width {
+		t.Fatalf("Len(), Width() = %d, %d, want %d, %d", p.Len(), p.Width(), n, width)
+	}
+
+	mask := ^(maskFF << uint(width))
+	want := make([]T, n)
+	for i := range want {
+		v := T(rand.Uint64())
+		p.Set(i, v)
+		want[i] = T(uint64(v) & mask)
+	}
+	// Overwriting must not disturb the neighbours.
+	for i := 0; i < n; i += 3 {
+		v := T(rand.Uint64())
+		p.Set(i, v)
+		want[i] = T(uint64(v) & mask)
+	}
+	for i := range want {
+		if got := p.Get(i); got != want[i] {
+			t.Errorf("width %d: Get(%d) = %d, want %d", width, i, got, want[i])
+		}
+	}
+}
+
+func TestPackedArray(t *testing.T) {
+	for _, width := range []int{0, 1, 3, 7, 8} {
+		testPackedArray[uint8](t, width)
+	}
+	for _, width := range []int{5, 11, 13, 16} {
+		testPackedArray[uint16](t, width)
+	}
+	for _, width := range []int{17, 20, 31, 32} {
+		testPackedArray[uint32](t, width)
+	}
+	for _, width := range []int{33, 63, 64} {
+		testPackedArray[uint64](t, width)
+	}
+}
+
+func TestNewPackedArrayTooWide(t *testing.T) {
+	mustPanic := func(name string, f func()) {
+		defer func() {
+			if r := recover(); r != "bitvector: PackedArray width out of range" {
+				t.Errorf("%s recovered %v", name, r)
+			}
+		}()
+		f()
+	}
+	mustPanic("NewPackedArray[uint8](10, 9)", func() { NewPackedArray[uint8](10, 9) })
+	mustPanic("NewPackedArray[uint8](10, 12)", func() { NewPackedArray[uint8](10, 12) })
+	mustPanic("NewPackedArray[uint16](10, 17)", func() { NewPackedArray[uint16](10, 17) })
+	mustPanic("NewPackedArray[uint32](10, 33)", func() { NewPackedArray[uint32](10, 33) })
+	mustPanic("NewPackedArray[uint64](10, 65)", func() { NewPackedArray[uint64](10, 65) })
+}
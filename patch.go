@@ -0,0 +1,59 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Patch is a set of bit flips that turns one bit vector into another.
+type Patch struct {
+	size   int    // the size of the bit vectors.
+	n      int    // the number of flipped bits.
+	deltas []byte // the gaps between flipped positions in uvarint.
+}
+
+// Diff returns the patch that turns a into b.
+func Diff(a, b *BitVector) (*Patch, error) {
+	if a.size != b.size {
+		return nil, ErrorSizeMismatch
+	}
+	p := &Patch{size: a.size}
+	prev := 0
+	for i := range a.numWords() {
+		x := a.word(i) ^ b.word(i)
+		for x != 0 {
+			pos := i*bitLength + bits.TrailingZeros64(x)
+			if pos >= a.size {
+				break
+			}
+			p.deltas = binary.AppendUvarint(p.deltas, uint64(pos-prev))
+			p.n++
+			prev = pos
+			x &= x - 1
+		}
+	}
+	return p, nil
+}
+
+// Len returns the number of bits flipped by the patch.
+func (p Patch) Len() int {
+	return p.n
+}
+
+// Apply returns the bit vector obtained by flipping the bits of a recorded in the patch.
+func (p *Patch) Apply(a *BitVector) (*BitVector, error) {
+	if a.size != p.size {
+		return nil, ErrorSizeMismatch
+	}
+	b := NewBuilder(a.size)
+	copy(b.v, a.words())
+	pos, data := 0, p.deltas
+	for range p.n {
+		delta, k := binary.Uvarint(data)
+		pos += int(delta)
+		b.v[pos/bitLength] ^= uint64(1) << uint(pos%bitLength)
+		data = data[k:]
+	}
+	b.clearTail()
+	return b.Build(), nil
+}
@@ -0,0 +1,38 @@
+package bitvector
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPatch(t *testing.T) {
+	_, a := random(1000)
+	_, b := random(1000)
+
+	p, err := Diff(a, b)
+	if err != nil {
+		t.Fatalf("Diff(): %v", err)
+	}
+	if count, _ := SymmetricDifferenceCount(a, b); p.Len() != count {
+		t.Errorf("Len() = %d, want %d", p.Len(), count)
+	}
+	c, err := p.Apply(a)
+	if err != nil {
+		t.Fatalf("Apply(): %v", err)
+	}
+	if c.Len() != b.Len() || !slices.Equal(c.Positions(), b.Positions()) {
+		t.Errorf("Apply(a) differs from b")
+	}
+
+	if p, _ := Diff(a, a); p.Len() != 0 {
+		t.Errorf("Diff(a, a).Len() = %d, want 0", p.Len())
+	}
+
+	_, short := random(999)
+	if _, err := Diff(a, short); err != ErrorSizeMismatch {
+		t.Errorf("Diff() of different sizes error = %v, want %v", err, ErrorSizeMismatch)
+	}
+	if _, err := p.Apply(short); err != ErrorSizeMismatch {
+		t.Errorf("Apply() to a different size error = %v, want %v", err, ErrorSizeMismatch)
+	}
+}
@@ -0,0 +1,66 @@
+package bitvector
+
+import (
+	"math/rand"
+	"time"
+)
+
+// BenchReport is the measured time of the queries on a bit vector, in nanoseconds per call.
+type BenchReport struct {
+	Get     float64
+	Rank1   float64
+	Select1 float64 // 0 if the bit vector has no 1s.
+}
+
+// Benchmark times n calls each of Get, Rank1 and Select1 on random arguments
+// from a fixed seed, so that an application can log the performance of its
+// bit vector. It returns a zero report if n is not positive or the bit vector is empty.
+func (b BitVector) Benchmark(n int) BenchReport {
+	var report BenchReport
+	if n <= 0 || b.size == 0 {
+		return report
+	}
+	r := rand.New(rand.NewSource(1))
+	args := make([]int, n)
+
+	timeCalls := func(limit int, f func(int)) float64 {
+		for k := range args {
+			args[k] = r.Intn(limit)
+		}
+		start := time.Now()
+		for _, i := range args {
+			f(i)
+		}
+		return float64(time.Since(start).Nanoseconds()) / float64(n)
+	}
+	report.Get = timeCalls(b.size, func(i int) { b.Get(i) })
+	report.Rank1 = timeCalls(b.size+1, func(i int) { b.Rank1(i) })
+	if ones := b.CountOnes(); ones > 0 {
+		report.Select1 = timeCalls(ones, func(i int) { b.Select1(i) })
+	}
+	return report
+}
+
+// BuildStats is the cost of building a bit vector.
+type BuildStats struct {
+	Duration   time.Duration // the time taken by Build.
+	DataBytes  int           // the bytes of the stored words, 0 for a constant bit vector.
+	IndexBytes int           // the bytes of the ranks and the other indexes.
+	Density    float64       // the fraction of 1s, 0 for an empty bit vector.
+}
+
+// BuildWithStats builds a BitVector from the builder and reports what it cost.
+func (b *Builder) BuildWithStats() (*BitVector, BuildStats) {
+	start := time.Now()
+	bv := b.Build()
+	stats := BuildStats{Duration: time.Since(start)}
+
+	if bv.store != nil {
+		stats.DataBytes = 8 * bv.store.Len()
+	}
+	stats.IndexBytes = bv.SizeInBytes() - stats.DataBytes
+	if bv.size > 0 {
+		stats.Density = float64(bv.CountOnes()) / float64(bv.size)
+	}
+	return bv, stats
+}
@@ -0,0 +1,49 @@
+package bitvector
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBenchmark(t *testing.T) {
+	_, bv := random(100000)
+	report := bv.Benchmark(1000)
+	for name, ns := range map[string]float64{"Get": report.Get, "Rank1": report.Rank1, "Select1": report.Select1} {
+		if !(ns > 0) || math.IsInf(ns, 0) {
+			t.Errorf("Benchmark().%s = %g ns/op", name, ns)
+		}
+	}
+
+	if got := NewBuilder(0).Build().Benchmark(1000); got != (BenchReport{}) {
+		t.Errorf("Benchmark() of an empty vector = %+v, want zero", got)
+	}
+	if got := bv.Benchmark(0); got != (BenchReport{}) {
+		t.Errorf("Benchmark(0) = %+v, want zero", got)
+	}
+}
+
+func TestBuildWithStats(t *testing.T) {
+	for _, size := range []int{100, 64 * 100, 100000} {
+		s, want := random(size)
+		b := NewBuilder(size)
+		for i, c := range s {
+			b.Set(i, c == '1')
+		}
+		bv, stats := b.BuildWithStats()
+		if !bv.Equal(want) {
+			t.Fatalf("size %d: BuildWithStats() built a different vector", size)
+		}
+		if stats.Duration < 0 {
+			t.Errorf("size %d: Duration = %v", size, stats.Duration)
+		}
+		if words := (size + bitLength) / bitLength; stats.DataBytes != 8*words || stats.DataBytes < (size+7)/8 {
+			t.Errorf("size %d: DataBytes = %d, want %d", size, stats.DataBytes, 8*words)
+		}
+		if stats.IndexBytes <= 0 || stats.DataBytes+stats.IndexBytes != bv.SizeInBytes() {
+			t.Errorf("size %d: IndexBytes = %d, DataBytes = %d, SizeInBytes() = %d", size, stats.IndexBytes, stats.DataBytes, bv.SizeInBytes())
+		}
+		if density := float64(bv.CountOnes()) / float64(size); stats.Density != density {
+			t.Errorf("size %d: Density = %g, want %g", size, stats.Density, density)
+		}
+	}
+}
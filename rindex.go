@@ -0,0 +1,201 @@
+package bitvector
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// RIndex is a run-length FM-index, the r-index, of a byte string, answering
+// the count and the positions of the occurrences of a pattern in space
+// proportional to the number of runs of equal bytes in the Burrows-Wheeler
+// transform of the text, which is laid out as in FMIndex.
+//
+// The transform without the sentinel is kept as the byte and the start of
+// each run, breaking the runs at the sentinel too, with the total length of
+// the runs of each byte before each run.
+// Locate keeps the position of the last row of the range of the pattern
+// through the backward search, from the position of the last row of a run
+// when the range moves to another run. It then lists the other positions
+// with phi, the position of the previous row of each position. Since
+// phi(p) = phi(p+1)-1 unless the row of p+1 starts a run, only the
+// positions before those rows are sampled.
+type RIndex struct {
+	n       int
+	dollar  int      // the row whose transform is the sentinel.
+	c       [257]int // the count of rows starting with a byte less than each byte.
+	heads   *WaveletMatrix
+	starts  *SDVector               // the start of each run in the transform without the sentinel.
+	lengths [256]*PackedArray[uint] // the total length of the runs of each byte before each of them, and of all of them.
+	ends    *PackedArray[uint]      // the position in the text of the last row of each run.
+	last    int                     // the position in the text of the last row.
+	keys    *SDVector               // the positions sampling phi.
+	phi     *PackedArray[uint]      // phi of each position of keys.
+}
+
+// NewRIndex makes an RIndex of text.
+func NewRIndex(text []byte) *RIndex {
+	n := len(text)
+	sa := append([]int{n}, suffixArray(text)...) // the position of each row, the sentinel first.
+	r := &RIndex{n: n, last: sa[n]}
+
+	// The transform without the sentinel and the positions of its rows.
+	bwt, positions := make([]byte, 0, n), make([]int, 0, n)
+	for j, pos := range sa {
+		if pos == 0 {
+			r.dollar = j
+		} else {
+			bwt, positions = append(bwt, text[pos-1]), append(positions, pos)
+		}
+	}
+	var heads []byte
+	var starts, ends []int
+	var lengths [256][]int
+	var total [256]int
+	for k, x := range bwt {
+		// The runs also break at the sentinel, so that the row before it ends one.
+		if k == 0 || bwt[k-1] != x || k == r.dollar {
+			heads, starts = append(heads, x), append(starts, k)
+			lengths[x] = append(lengths[x], total[x])
+		}
+		total[x]++
+		if k == n-1 || bwt[k+1] != x || k+1 == r.dollar {
+			ends = append(ends, positions[k])
+		}
+	}
+	for x := range lengths {
+		r.lengths[x] = packInts(append(lengths[x], total[x]), n)
+	}
+	r.ends = packInts(ends, n)
+	r.heads = NewWaveletMatrix(heads)
+	r.starts, _ = NewSDVector(starts, n)
+
+	r.c[0] = 1
+	for x := range 256 {
+		r.c[x+1] = r.c[x] + total[x]
+	}
+
+	// Sample phi before the rows starting a run of the transform with the sentinel.
+	inverse := make([]int, n+1)
+	for j, pos := range sa {
+		inverse[pos] = j
+	}
+	var keys []int
+	for j, pos := range sa {
+		if pos > 0 && (j == 0 || j == r.dollar+1 || text[pos-1] != text[sa[j-1]-1]) {
+			keys = append(keys, pos-1)
+		}
+	}
+	slices.Sort(keys)
+	r.keys, _ = NewSDVector(keys, n)
+	phi := make([]int, len(keys))
+	for k, pos := range keys {
+		phi[k] = sa[inverse[pos]-1]
+	}
+	r.phi = packInts(phi, n)
+	return r
+}
+
+// packInts returns values, which must be in [0, limit], in a PackedArray.
+func packInts(values []int, limit int) *PackedArray[uint] {
+	p := NewPackedArray[uint](len(values), bits.Len(uint(limit)))
+	for i, v := range values {
+		p.Set(i, uint(v))
+	}
+	return p
+}
+
+// Len returns the length of the text.
+func (r RIndex) Len() int {
+	return r.n
+}
+
+// Runs returns the number of runs of the transform without the sentinel.
+func (r RIndex) Runs() int {
+	return r.ends.Len()
+}
+
+// SizeInBytes returns the number of bytes used by the index.
+func (r RIndex) SizeInBytes() int {
+	size := r.starts.SizeInBytes() + r.keys.SizeInBytes()
+	for _, b := range r.heads.levels {
+		size += b.SizeInBytes()
+	}
+	for _, p := range append(r.lengths[:], r.ends, r.phi) {
+		size += (p.Len()*p.Width() + 7) / 8
+	}
+	return size
+}
+
+// run returns the run holding the k-th byte of the transform without the sentinel.
+func (r RIndex) run(k int) int {
+	run, _ := r.starts.Rank1(k + 1)
+	return run - 1
+}
+
+// reduced returns the count of rows before row j but that of the sentinel.
+func (r RIndex) reduced(j int) int {
+	if j > r.dollar {
+		return j - 1
+	}
+	return j
+}
+
+// occ returns the count of byte x in the transform before row j.
+func (r RIndex) occ(x byte, j int) int {
+	if j = r.reduced(j); j == 0 {
+		return 0
+	}
+	run := r.run(j - 1)
+	runs, _ := r.heads.Rank(x, run)
+	count := int(r.lengths[x].Get(runs))
+	if head, _ := r.heads.Access(run); head == x {
+		start, _ := r.starts.Select1(run)
+		count += j - start
+	}
+	return count
+}
+
+// rows returns the range of rows starting with pattern, and the position of the last of them.
+func (r RIndex) rows(pattern []byte) (int, int, int) {
+	s, e, last := 0, r.n+1, r.last
+	for i := len(pattern) - 1; i >= 0 && s < e; i-- {
+		x := pattern[i]
+		// Follow the last row with x in the range to the last row of the next one.
+		if k := r.reduced(e) - 1; k >= 0 {
+			run := r.run(k)
+			if head, _ := r.heads.Access(run); head == x && e-1 != r.dollar {
+				last--
+			} else if runs, _ := r.heads.Rank(x, run+1); runs > 0 {
+				run, _ = r.heads.Select(x, runs-1)
+				last = int(r.ends.Get(run)) - 1
+			}
+		}
+		s, e = r.c[x]+r.occ(x, s), r.c[x]+r.occ(x, e)
+	}
+	if len(pattern) == 0 {
+		s = 1 // every row but that of the sentinel.
+	}
+	return s, max(s, e), last
+}
+
+// Count returns the number of occurrences of pattern in the text.
+func (r RIndex) Count(pattern []byte) int {
+	s, e, _ := r.rows(pattern)
+	return e - s
+}
+
+// Locate returns the positions of the occurrences of pattern in the text in ascending order.
+func (r RIndex) Locate(pattern []byte) []int {
+	s, e, pos := r.rows(pattern)
+	positions := make([]int, 0, e-s)
+	for j := e - 1; j >= s; j-- {
+		positions = append(positions, pos)
+		if j > s {
+			k, _ := r.keys.Rank1(pos)
+			key, _ := r.keys.Select1(k)
+			pos = int(r.phi.Get(k)) - (key - pos)
+		}
+	}
+	slices.Sort(positions)
+	return positions
+}
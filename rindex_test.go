@@ -0,0 +1,73 @@
+package bitvector
+
+import (
+	"bytes"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// repetitiveText returns copies of a random text of length n with a few random edits each.
+func repetitiveText(n, copies, edits int) []byte {
+	base := randomBytes(n, 4)
+	var text []byte
+	for range copies {
+		c := bytes.Clone(base)
+		for range edits {
+			c[rand.Intn(n)] = byte(rand.Intn(4))
+		}
+		text = append(text, c...)
+	}
+	return text
+}
+
+func TestRIndex(t *testing.T) {
+	for _, text := range [][]byte{
+		nil,
+		[]byte("a"),
+		[]byte("aaaa"),
+		[]byte("abracadabra"),
+		[]byte("mississippi"),
+		{0, 0, 1, 0, 0},
+		randomBytes(2000, 3),
+		repetitiveText(300, 10, 3),
+	} {
+		r := NewRIndex(text)
+		if r.Len() != len(text) {
+			t.Errorf("Len() = %d, want %d", r.Len(), len(text))
+		}
+		patterns := [][]byte{nil, []byte("a"), []byte("abra"), []byte("ss"), []byte("i"), {0}, {0, 0}, {1, 0}, {9}}
+		for i := 0; i+5 <= len(text); i += 37 {
+			patterns = append(patterns, text[i:i+1], text[i:i+3], text[i:i+5])
+		}
+		for _, pattern := range patterns {
+			want := occurrences(text, pattern)
+			if got := r.Count(pattern); got != len(want) {
+				t.Errorf("%q: Count(%q) = %d, want %d", text[:min(len(text), 20)], pattern, got, len(want))
+			}
+			if got := r.Locate(pattern); !slices.Equal(got, want) {
+				t.Errorf("%q: Locate(%q) = %v, want %v", text[:min(len(text), 20)], pattern, got, want)
+			}
+		}
+	}
+}
+
+func TestRIndexRepetitive(t *testing.T) {
+	text := repetitiveText(2000, 100, 2)
+	r := NewRIndex(text)
+	if runs := r.Runs(); runs > len(text)/20 {
+		t.Errorf("Runs() = %d for %d bytes of text", runs, len(text))
+	}
+	f := NewFMIndex(text, 32)
+	size := 0
+	for _, b := range f.bwt.levels {
+		size += b.SizeInBytes()
+	}
+	if r.SizeInBytes() >= size {
+		t.Errorf("SizeInBytes() = %d, want less than the %d bytes of the FMIndex transform", r.SizeInBytes(), size)
+	}
+	pattern := text[12345:12360]
+	if got, want := r.Locate(pattern), f.Locate(pattern); !slices.Equal(got, want) {
+		t.Errorf("Locate() = %v, want %v", got, want)
+	}
+}
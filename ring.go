@@ -0,0 +1,110 @@
+package bitvector
+
+import "math/bits"
+
+// RingBitVector is a fixed-capacity bit vector over a sliding window of positions.
+// Position i is stored in slot i mod capacity, and only the last capacity
+// positions up to the highest one set are live.
+type RingBitVector struct {
+	capacity int      // the number of live positions.
+	end      int      // one past the highest position set.
+	v        []uint64 // the slots.
+}
+
+// NewRingBitVector makes a new ring bit vector of the specified capacity.
+func NewRingBitVector(capacity int) *RingBitVector {
+	return &RingBitVector{
+		capacity: capacity,
+		v:        make([]uint64, capacity/bitLength+1),
+	}
+}
+
+// Cap returns the capacity of the ring bit vector.
+func (r RingBitVector) Cap() int {
+	return r.capacity
+}
+
+// Start returns the first live position.
+func (r RingBitVector) Start() int {
+	return max(0, r.end-r.capacity)
+}
+
+// End returns one past the last live position.
+func (r RingBitVector) End() int {
+	return r.end
+}
+
+// Set sets the bit at position i to v. Setting a position beyond End slides
+// the window forward, clearing the positions that become live.
+// Positions before Start have expired and cannot be set.
+func (r *RingBitVector) Set(i int, v bool) error {
+	if i < r.Start() || r.capacity == 0 {
+		return ErrorOutOfRange
+	}
+	if i >= r.end {
+		n := min(i+1-r.end, r.capacity)
+		r.slots(i+1-n, n, func(k int, mask uint64) {
+			r.v[k] &^= mask
+		})
+		r.end = i + 1
+	}
+
+	slot := i % r.capacity
+	if v {
+		r.v[slot/bitLength] |= uint64(1) << uint(slot%bitLength)
+	} else {
+		r.v[slot/bitLength] &^= uint64(1) << uint(slot%bitLength)
+	}
+	return nil
+}
+
+// Get returns true or false, the value of the bit at position i.
+func (r RingBitVector) Get(i int) (bool, error) {
+	if i < r.Start() || i >= r.end {
+		return false, ErrorOutOfRange
+	}
+	slot := i % r.capacity
+	return (r.v[slot/bitLength]>>uint(slot%bitLength))&1 == 1, nil
+}
+
+// Rank1 returns the count of 1s in the live positions before position i.
+func (r RingBitVector) Rank1(i int) (int, error) {
+	start := r.Start()
+	if i < start || i > r.end {
+		return 0, ErrorOutOfRange
+	}
+	count := 0
+	r.slots(start, i-start, func(k int, mask uint64) {
+		count += bits.OnesCount64(r.v[k] & mask)
+	})
+	return count, nil
+}
+
+// slots calls f with each word index and the mask of the slots in it that
+// hold the n positions from position i.
+func (r RingBitVector) slots(i, n int, f func(k int, mask uint64)) {
+	if n == 0 {
+		return
+	}
+	lo := i % r.capacity
+	hi := lo + n
+	if hi > r.capacity {
+		r.linearSlots(0, hi-r.capacity, f)
+		hi = r.capacity
+	}
+	r.linearSlots(lo, hi, f)
+}
+
+// linearSlots calls f with each word index and the mask of the slots in it within [lo, hi).
+func (r RingBitVector) linearSlots(lo, hi int, f func(k int, mask uint64)) {
+	for k := lo / bitLength; k*bitLength < hi; k++ {
+		mask := maskFF
+		if k == lo/bitLength {
+			mask &= maskFF << uint(lo%bitLength)
+		}
+		if k == (hi-1)/bitLength && hi%bitLength != 0 {
+			mask &= ^(maskFF << uint(hi%bitLength))
+		}
+		f(k, mask)
+	}
+}
@@ -0,0 +1,72 @@
+package bitvector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRingBitVector(t *testing.T) {
+	const capacity = 100
+	r := NewRingBitVector(capacity)
+	ref := map[int]bool{}
+
+	pos := 0
+	for step := 0; step < 2000; step++ {
+		pos += rand.Intn(5)
+		v := rand.Intn(2) == 1
+		if err := r.Set(pos, v); err != nil {
+			t.Fatalf("Set(%d): %v", pos, err)
+		}
+		ref[pos] = v
+		// Occasionally rewrite a live position behind the head.
+		if back := pos - rand.Intn(capacity); back >= r.Start() && step%3 == 0 {
+			r.Set(back, !ref[back])
+			ref[back] = !ref[back]
+		}
+
+		if r.End() != pos+1 || r.Start() != max(0, pos+1-capacity) {
+			t.Fatalf("window = [%d, %d) after Set(%d)", r.Start(), r.End(), pos)
+		}
+		if step%50 != 0 {
+			continue
+		}
+		count := 0
+		for i := r.Start(); i <= r.End(); i++ {
+			if got, err := r.Rank1(i); err != nil || got != count {
+				t.Fatalf("Rank1(%d) = %d, %v, want %d", i, got, err, count)
+			}
+			if i == r.End() {
+				break
+			}
+			if got, _ := r.Get(i); got != ref[i] {
+				t.Fatalf("Get(%d) = %v, want %v", i, got, ref[i])
+			}
+			if ref[i] {
+				count++
+			}
+		}
+	}
+
+	if err := r.Set(r.Start()-1, true); err != ErrorOutOfRange {
+		t.Errorf("Set() of an expired position error = %v, want %v", err, ErrorOutOfRange)
+	}
+	if _, err := r.Get(r.End()); err != ErrorOutOfRange {
+		t.Errorf("Get(End()) error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
+
+func TestRingBitVectorJump(t *testing.T) {
+	r := NewRingBitVector(70)
+	for i := 0; i < 70; i++ {
+		r.Set(i, true)
+	}
+	// Jumping far ahead clears every slot.
+	r.Set(1000, false)
+	if got, _ := r.Rank1(r.End()); got != 0 {
+		t.Errorf("Rank1(End()) = %d after a jump, want 0", got)
+	}
+	r.Set(1005, true)
+	if got, _ := r.Rank1(r.End()); got != 1 {
+		t.Errorf("Rank1(End()) = %d, want 1", got)
+	}
+}
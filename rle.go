@@ -0,0 +1,146 @@
+package bitvector
+
+import "sort"
+
+// RLEVector is a bit vector stored as its runs of 1s, which takes space
+// proportional to the number of runs rather than to the size.
+type RLEVector struct {
+	size   int   // size of the bit vector.
+	starts []int // the start of each run of 1s.
+	ones   []int // the count of 1s before each run, then the count of all 1s.
+}
+
+var _ RankSelect = RLEVector{}
+
+// NewRLEVector makes an RLEVector of the bits of b.
+func NewRLEVector(b *BitVector) *RLEVector {
+	r := &RLEVector{size: b.size}
+	one := b.size > 0 && b.word(0)&1 == 1
+	if one {
+		r.starts = append(r.starts, 0)
+	}
+	count := 0
+	for pos := range b.Transitions() {
+		if one {
+			count += pos - r.starts[len(r.starts)-1]
+			r.ones = append(r.ones, count)
+		} else {
+			r.starts = append(r.starts, pos)
+		}
+		one = !one
+	}
+	if one {
+		count += b.size - r.starts[len(r.starts)-1]
+		r.ones = append(r.ones, count)
+	}
+	// Shift the counts after each run to be the counts before it.
+	r.ones = append([]int{0}, r.ones...)
+	return r
+}
+
+// NewRLEVectorFromRuns makes an RLEVector of the specified size whose 1s are
+// the runs given as [start, length] pairs in increasing order of start.
+// Overlapping and adjacent runs are merged. It returns ErrorNotSorted if the
+// runs are out of order and ErrorOutOfRange if one is out of [0, size).
+func NewRLEVectorFromRuns(runs [][2]int, size int) (*RLEVector, error) {
+	r := &RLEVector{size: size, ones: []int{0}}
+	end := -1 // the end of the last run.
+	for j, run := range runs {
+		start, length := run[0], run[1]
+		if start < 0 || length < 0 || start > size-length {
+			return nil, ErrorOutOfRange
+		}
+		if j > 0 && start < runs[j-1][0] {
+			return nil, ErrorNotSorted
+		}
+		switch {
+		case length == 0 || start+length <= end:
+			continue
+		case start <= end:
+			r.ones[len(r.ones)-1] += start + length - end
+		default:
+			r.starts = append(r.starts, start)
+			r.ones = append(r.ones, r.ones[len(r.ones)-1]+length)
+		}
+		end = start + length
+	}
+	return r, nil
+}
+
+// Len returns the size of the bit vector.
+func (r RLEVector) Len() int {
+	return r.size
+}
+
+// CountOnes returns the count of 1s in the bit vector.
+func (r RLEVector) CountOnes() int {
+	return r.ones[len(r.starts)]
+}
+
+// RunCount1 returns the number of maximal runs of 1s in the bit vector.
+func (r RLEVector) RunCount1() int {
+	return len(r.starts)
+}
+
+// SizeInBytes returns the number of bytes used by the runs.
+func (r RLEVector) SizeInBytes() int {
+	return 8 * (len(r.starts) + len(r.ones))
+}
+
+// Get returns true or false, the value of the i-th bit.
+func (r RLEVector) Get(i int) (bool, error) {
+	if i < 0 || i >= r.size {
+		return false, ErrorOutOfRange
+	}
+	j := sort.SearchInts(r.starts, i+1) - 1
+	return j >= 0 && i < r.starts[j]+r.ones[j+1]-r.ones[j], nil
+}
+
+// Rank1 returns the count of 1s before the i-th bit.
+func (r RLEVector) Rank1(i int) (int, error) {
+	if i < 0 || i > r.size {
+		return 0, ErrorOutOfRange
+	}
+	// The i-th bit follows the start of the j-th run and no other.
+	j := sort.SearchInts(r.starts, i+1) - 1
+	if j < 0 {
+		return 0, nil
+	}
+	return r.ones[j] + min(i-r.starts[j], r.ones[j+1]-r.ones[j]), nil
+}
+
+// Rank0 returns the count of 0s before the i-th bit.
+func (r RLEVector) Rank0(i int) (int, error) {
+	val, err := r.Rank1(i)
+	if err != nil {
+		return 0, err
+	}
+	return i - val, nil
+}
+
+// Select1 returns the index of the i-th 1. It returns ErrorOutOfRange for
+// a negative i and ErrorNotExist if there are not more than i 1s.
+func (r RLEVector) Select1(i int) (int, error) {
+	if i < 0 {
+		return 0, ErrorOutOfRange
+	}
+	if i >= r.CountOnes() {
+		return 0, ErrorNotExist
+	}
+	j := sort.SearchInts(r.ones, i+1) - 1
+	return r.starts[j] + i - r.ones[j], nil
+}
+
+// Select0 returns the index of the i-th 0. It returns ErrorOutOfRange for
+// a negative i and ErrorNotExist if there are not more than i 0s.
+func (r RLEVector) Select0(i int) (int, error) {
+	if i < 0 {
+		return 0, ErrorOutOfRange
+	}
+	if i >= r.size-r.CountOnes() {
+		return 0, ErrorNotExist
+	}
+	// The i-th 0 precedes the first run with more than i 0s before it.
+	j := sort.Search(len(r.starts), func(j int) bool { return r.starts[j]-r.ones[j] > i })
+	return i + r.ones[j], nil
+}
@@ -0,0 +1,67 @@
+package bitvector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// randomRuns returns runs of random lengths up to maxRun, in increasing order.
+func randomRuns(size, maxRun int) [][2]int {
+	var runs [][2]int
+	for pos := rand.Intn(maxRun + 1); pos < size; {
+		length := min(rand.Intn(maxRun)+1, size-pos)
+		runs = append(runs, [2]int{pos, length})
+		pos += length + rand.Intn(maxRun) + 1
+	}
+	return runs
+}
+
+func TestRLEVector(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 1000, 5000} {
+		for _, maxRun := range []int{1, 5, 300} {
+			want, _ := NewFromRuns(randomRuns(size, maxRun), size)
+			r := NewRLEVector(want)
+			sameRankSelect(t, r, want)
+			if r.RunCount1() != want.RunCount1() {
+				t.Errorf("RunCount1() = %d, want %d", r.RunCount1(), want.RunCount1())
+			}
+		}
+		_, want := random(size)
+		sameRankSelect(t, NewRLEVector(want), want)
+		all := NewBuilder(size)
+		all.SetAll()
+		want = all.Build()
+		sameRankSelect(t, NewRLEVector(want), want)
+	}
+}
+
+func TestNewRLEVectorFromRuns(t *testing.T) {
+	for _, runs := range [][][2]int{
+		nil,
+		{{0, 10}},
+		{{3, 2}, {5, 1}, {5, 4}, {7, 1}, {20, 0}, {30, 70}},
+		randomRuns(100, 10),
+	} {
+		want, _ := NewFromRuns(runs, 100)
+		r, err := NewRLEVectorFromRuns(runs, 100)
+		if err != nil {
+			t.Fatalf("NewRLEVectorFromRuns(%v): %v", runs, err)
+		}
+		sameRankSelect(t, r, want)
+	}
+
+	if _, err := NewRLEVectorFromRuns([][2]int{{5, 1}, {3, 1}}, 10); err != ErrorNotSorted {
+		t.Errorf("NewRLEVectorFromRuns() of unsorted runs error = %v, want %v", err, ErrorNotSorted)
+	}
+	if _, err := NewRLEVectorFromRuns([][2]int{{5, 6}}, 10); err != ErrorOutOfRange {
+		t.Errorf("NewRLEVectorFromRuns() of a run beyond the size error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
+
+func TestRLEVectorSize(t *testing.T) {
+	const size = 1000000
+	want, _ := NewFromRuns(randomRuns(size, 10000), size)
+	if r := NewRLEVector(want); r.SizeInBytes()*100 > want.SizeInBytes() {
+		t.Errorf("SizeInBytes() = %d, want at most 1%% of %d", r.SizeInBytes(), want.SizeInBytes())
+	}
+}
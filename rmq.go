@@ -0,0 +1,189 @@
+package bitvector
+
+import "cmp"
+
+// RMQ answers range minimum queries over a sequence without keeping it, from
+// the balanced parentheses of its Cartesian tree in 2n bits plus a min-excess
+// index. Reading the values from left to right, the parentheses pop with a 0
+// every value on a stack greater than the next value, then push it with a 1.
+//
+// The minimum of values[i..j] is then the lowest value on the stack after
+// pushing j among those pushed since i: the value pushed right after the last
+// lowest point of the excess, the count of 1s minus 0s, between the pushes
+// of i and j, or i itself if the excess never drops below its push.
+type RMQ struct {
+	n       int
+	parens  *BitVector
+	wordMin []int8 // the least excess after each bit of a word, relative to the excess before the word.
+	tree    []int  // the segment tree of the least excess within each superblock, with leaves from leaves.
+	leaves  int
+}
+
+// NewRMQ makes an RMQ over values.
+func NewRMQ[T cmp.Ordered](values []T) *RMQ {
+	n := len(values)
+	b := NewBuilderWithSelectIndex(2 * n)
+	pos := 0
+	var stack []T
+	for _, x := range values {
+		for len(stack) > 0 && stack[len(stack)-1] > x {
+			stack = stack[:len(stack)-1]
+			pos++
+		}
+		stack = append(stack, x)
+		b.Set1(pos)
+		pos++
+	}
+	r := &RMQ{n: n, parens: b.Build()}
+
+	words := (2*n + bitLength - 1) / bitLength
+	r.wordMin = make([]int8, words)
+	supers := (words + superblockWords - 1) / superblockWords
+	for r.leaves = 1; r.leaves < supers; r.leaves *= 2 {
+	}
+	r.tree = make([]int, 2*r.leaves)
+	for s := range r.tree {
+		r.tree[s] = maxInt
+	}
+	for k := range words {
+		base := r.excess(k * bitLength)
+		excess, least := 0, bitLength
+		for t := k * bitLength; t < min((k+1)*bitLength, 2*n); t++ {
+			if x, _ := r.parens.Get(t); x {
+				excess++
+			} else {
+				excess--
+			}
+			least = min(least, excess)
+		}
+		r.wordMin[k] = int8(least)
+		leaf := r.leaves + k/superblockWords
+		r.tree[leaf] = min(r.tree[leaf], base+least)
+	}
+	for s := r.leaves - 1; s > 0; s-- {
+		r.tree[s] = min(r.tree[2*s], r.tree[2*s+1])
+	}
+	return r
+}
+
+// Len returns the length of the sequence.
+func (r RMQ) Len() int {
+	return r.n
+}
+
+// SizeInBytes returns the number of bytes used by the parentheses and the index.
+func (r RMQ) SizeInBytes() int {
+	return r.parens.SizeInBytes() + len(r.wordMin) + 8*len(r.tree)
+}
+
+// RMQ returns the index of the leftmost minimum of values[i..j], inclusive.
+func (r RMQ) RMQ(i, j int) (int, error) {
+	if i < 0 || j >= r.n || i > j {
+		return 0, ErrorOutOfRange
+	}
+	pi, _ := r.parens.Select1(i)
+	pj, _ := r.parens.Select1(j)
+	least, m := r.lastMin(pi, pj)
+	if least == r.excess(pi+1) {
+		return i, nil
+	}
+	return r.parens.Rank1(m + 1)
+}
+
+// excess returns the count of 1s minus the count of 0s before the t-th bit.
+func (r RMQ) excess(t int) int {
+	ones, _ := r.parens.Rank1(t)
+	return 2*ones - t
+}
+
+// lastMin returns the least excess after the bits in [l, r], and the last bit after which it is reached.
+func (r RMQ) lastMin(l, rt int) (int, int) {
+	kl, kr := l/bitLength, rt/bitLength
+	if kr-kl <= 1 {
+		return r.scan(l, rt)
+	}
+	least, m := r.scan(l, (kl+1)*bitLength-1)
+	// block is the word or superblock holding the least excess if it is not among the bits scanned.
+	block, isSuper := -1, false
+	update := func(x, k int, super bool) {
+		if x <= least {
+			least, m, block, isSuper = x, -1, k, super
+		}
+	}
+	sl := (kl + superblockWords) / superblockWords // the first superblock after kl
+	sr := kr / superblockWords                     // the superblock of kr
+	if sl >= sr {
+		for k := kl + 1; k < kr; k++ {
+			update(r.wordLeast(k), k, false)
+		}
+	} else {
+		for k := kl + 1; k < sl*superblockWords; k++ {
+			update(r.wordLeast(k), k, false)
+		}
+		x, s := r.query(1, 0, r.leaves, sl, sr)
+		update(x, s, true)
+		for k := sr * superblockWords; k < kr; k++ {
+			update(r.wordLeast(k), k, false)
+		}
+	}
+	if x, t := r.scan(kr*bitLength, rt); x <= least {
+		return x, t
+	}
+
+	if isSuper {
+		for k := min((block+1)*superblockWords, kr) - 1; ; k-- {
+			if r.wordLeast(k) == least {
+				block = k
+				break
+			}
+		}
+	}
+	if block >= 0 {
+		return r.scan(block*bitLength, (block+1)*bitLength-1)
+	}
+	return least, m
+}
+
+// wordLeast returns the least excess after a bit of the k-th word.
+func (r RMQ) wordLeast(k int) int {
+	return r.excess(k*bitLength) + int(r.wordMin[k])
+}
+
+// scan returns the least excess after the bits in [l, r] and the last bit after which it is reached.
+func (r RMQ) scan(l, rt int) (int, int) {
+	excess := r.excess(l)
+	least, m := maxInt, l
+	for t := l; t <= rt; {
+		x := r.parens.word(t/bitLength) >> uint(t%bitLength)
+		for end := min(rt, t|(bitLength-1)); t <= end; t, x = t+1, x>>1 {
+			excess += 2*int(x&1) - 1
+			if excess <= least {
+				least, m = excess, t
+			}
+		}
+	}
+	return least, m
+}
+
+// query returns the least excess within the superblocks in [a, b) covered by
+// the node of the segment tree over [lo, hi), and the last superblock reaching it.
+func (r RMQ) query(node, lo, hi, a, b int) (int, int) {
+	if b <= lo || hi <= a || r.tree[node] == maxInt {
+		return maxInt, -1
+	}
+	if a <= lo && hi <= b {
+		// Follow the last child reaching the least excess of the node.
+		for node < r.leaves {
+			if node = 2*node + 1; r.tree[node] != r.tree[node/2] {
+				node--
+			}
+		}
+		return r.tree[node], node - r.leaves
+	}
+	mid := (lo + hi) / 2
+	x, s := r.query(2*node+1, mid, hi, a, b)
+	if y, u := r.query(2*node, lo, mid, a, b); y < x {
+		return y, u
+	}
+	return x, s
+}
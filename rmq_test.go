@@ -0,0 +1,87 @@
+package bitvector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRMQ(t *testing.T) {
+	for _, n := range []int{1, 2, 10, 100, 3000} {
+		for _, alphabet := range []int{2, 10, 1 << 30} {
+			values := make([]int, n)
+			for i := range values {
+				values[i] = rand.Intn(alphabet)
+			}
+			r := NewRMQ(values)
+			if r.Len() != n {
+				t.Errorf("Len() = %d, want %d", r.Len(), n)
+			}
+			for range 2000 {
+				i, j := rand.Intn(n), rand.Intn(n)
+				if i > j {
+					i, j = j, i
+				}
+				want := i
+				for k := i + 1; k <= j; k++ {
+					if values[k] < values[want] {
+						want = k
+					}
+				}
+				if got, err := r.RMQ(i, j); err != nil || got != want {
+					t.Fatalf("n=%d: RMQ(%d, %d) = %d, %v, want %d", n, i, j, got, err, want)
+				}
+			}
+		}
+	}
+}
+
+func TestRMQMonotone(t *testing.T) {
+	const n = 5000
+	up, down := make([]int, n), make([]int, n)
+	for i := range n {
+		up[i], down[i] = i, n-i
+	}
+	ru, rd := NewRMQ(up), NewRMQ(down)
+	for range 1000 {
+		i, j := rand.Intn(n), rand.Intn(n)
+		if i > j {
+			i, j = j, i
+		}
+		if got, _ := ru.RMQ(i, j); got != i {
+			t.Errorf("increasing: RMQ(%d, %d) = %d, want %d", i, j, got, i)
+		}
+		if got, _ := rd.RMQ(i, j); got != j {
+			t.Errorf("decreasing: RMQ(%d, %d) = %d, want %d", i, j, got, j)
+		}
+	}
+}
+
+func TestRMQOutOfRange(t *testing.T) {
+	r := NewRMQ([]string{"b", "a", "c"})
+	if got, _ := r.RMQ(0, 2); got != 1 {
+		t.Errorf("RMQ(0, 2) = %d, want 1", got)
+	}
+	for _, q := range [][2]int{{-1, 0}, {0, 3}, {2, 1}} {
+		if _, err := r.RMQ(q[0], q[1]); err != ErrorOutOfRange {
+			t.Errorf("RMQ(%d, %d) error = %v, want ErrorOutOfRange", q[0], q[1], err)
+		}
+	}
+	if _, err := NewRMQ([]int(nil)).RMQ(0, 0); err != ErrorOutOfRange {
+		t.Errorf("empty RMQ(0, 0) error = %v, want ErrorOutOfRange", err)
+	}
+}
+
+func BenchmarkRMQ(b *testing.B) {
+	const n = 1 << 20
+	values := make([]int, n)
+	for i := range values {
+		values[i] = rand.Int()
+	}
+	r := NewRMQ(values)
+	b.ResetTimer()
+	for range b.N {
+		i := rand.Intn(n)
+		r.RMQ(i, min(n-1, i+rand.Intn(n)))
+	}
+	b.ReportMetric(float64(8*r.SizeInBytes())/n, "bits/value")
+}
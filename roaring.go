@@ -0,0 +1,171 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// The portable serialization format of Roaring bitmaps, shared by the Go, Java
+// and C implementations, splits the 32-bit values into containers of the
+// values sharing their high 16 bits:
+//
+//	cookie      uint32 roaringCookieNoRuns, then the number of containers as uint32,
+//	            or roaringCookie | (containers-1)<<16, then a bitset of the run containers
+//	headers     uint16 high bits and uint16 cardinality-1 for each container
+//	offsets     uint32 offset of each container, unless there are run containers
+//	            and fewer than roaringNoOffsetThreshold containers
+//	containers  for each container, its sorted low bits as uint16s if it has at most
+//	            roaringArrayMax values, or else its bits as 1024 uint64s,
+//	            or for a run container the number of runs and the uint16 start
+//	            and length-1 of each run
+//
+// All fields are little-endian.
+const (
+	roaringCookie            = 12347
+	roaringCookieNoRuns      = 12346
+	roaringNoOffsetThreshold = 4
+	roaringArrayMax          = 4096
+	roaringContainerBits     = 1 << 16
+	roaringContainerWords    = roaringContainerBits / bitLength
+)
+
+// ToRoaring encodes the positions of the 1s in the portable serialization
+// format of Roaring bitmaps, which roaring.Bitmap.UnmarshalBinary and the
+// other Roaring implementations read. It returns ErrorOutOfRange if the
+// size exceeds the 32-bit values of Roaring.
+func (b BitVector) ToRoaring() ([]byte, error) {
+	if uint64(b.size) > 1<<32 {
+		return nil, ErrorOutOfRange
+	}
+	var keys, cards []int
+	for key := 0; key*roaringContainerBits < b.size; key++ {
+		card := 0
+		for k := key * roaringContainerWords; k < (key+1)*roaringContainerWords; k++ {
+			card += bits.OnesCount64(b.word(k))
+		}
+		if card > 0 {
+			keys, cards = append(keys, key), append(cards, card)
+		}
+	}
+
+	data := binary.LittleEndian.AppendUint32(nil, roaringCookieNoRuns)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(keys)))
+	for j, key := range keys {
+		data = binary.LittleEndian.AppendUint16(data, uint16(key))
+		data = binary.LittleEndian.AppendUint16(data, uint16(cards[j]-1))
+	}
+	offset := len(data) + 4*len(keys)
+	for _, card := range cards {
+		data = binary.LittleEndian.AppendUint32(data, uint32(offset))
+		if card > roaringArrayMax {
+			offset += 8 * roaringContainerWords
+		} else {
+			offset += 2 * card
+		}
+	}
+	for j, key := range keys {
+		first := key * roaringContainerWords
+		if cards[j] > roaringArrayMax {
+			for k := first; k < first+roaringContainerWords; k++ {
+				data = binary.LittleEndian.AppendUint64(data, b.word(k))
+			}
+			continue
+		}
+		for k := first; k < first+roaringContainerWords; k++ {
+			for x := b.word(k); x != 0; x &= x - 1 {
+				data = binary.LittleEndian.AppendUint16(data, uint16((k-first)*bitLength+bits.TrailingZeros64(x)))
+			}
+		}
+	}
+	return data, nil
+}
+
+// FromRoaring builds a BitVector of the specified size whose 1s are the values
+// of a Roaring bitmap in the portable serialization format, as written by
+// roaring.Bitmap.MarshalBinary. It returns ErrorInvalidFormat if data is not
+// in that format and ErrorOutOfRange if a value is not below the size.
+func FromRoaring(data []byte, size int) (*BitVector, error) {
+	if len(data) < 4 {
+		return nil, ErrorInvalidFormat
+	}
+	var n int
+	var runs []byte // the bitset of the run containers.
+	cookie := binary.LittleEndian.Uint32(data)
+	switch {
+	case cookie == roaringCookieNoRuns && len(data) >= 8:
+		n = int(binary.LittleEndian.Uint32(data[4:]))
+		data = data[8:]
+	case cookie&0xffff == roaringCookie:
+		n = int(cookie>>16) + 1
+		if len(data) < 4+(n+7)/8 {
+			return nil, ErrorInvalidFormat
+		}
+		runs, data = data[4:4+(n+7)/8], data[4+(n+7)/8:]
+	default:
+		return nil, ErrorInvalidFormat
+	}
+	if n > roaringContainerBits || len(data) < 4*n {
+		return nil, ErrorInvalidFormat
+	}
+	headers := data[:4*n]
+	data = data[4*n:]
+	if runs == nil || n >= roaringNoOffsetThreshold {
+		if len(data) < 4*n {
+			return nil, ErrorInvalidFormat
+		}
+		data = data[4*n:]
+	}
+
+	b := NewBuilder(size)
+	for j := range n {
+		base := int(binary.LittleEndian.Uint16(headers[4*j:])) * roaringContainerBits
+		card := int(binary.LittleEndian.Uint16(headers[4*j+2:])) + 1
+		switch {
+		case runs != nil && runs[j/8]>>uint(j%8)&1 == 1:
+			if len(data) < 2 {
+				return nil, ErrorInvalidFormat
+			}
+			count := int(binary.LittleEndian.Uint16(data))
+			if len(data) < 2+4*count {
+				return nil, ErrorInvalidFormat
+			}
+			for r := range count {
+				start := base + int(binary.LittleEndian.Uint16(data[2+4*r:]))
+				end := start + int(binary.LittleEndian.Uint16(data[4+4*r:])) + 1
+				if end > size {
+					return nil, ErrorOutOfRange
+				}
+				b.SetRange(start, end)
+			}
+			data = data[2+4*count:]
+		case card > roaringArrayMax:
+			if len(data) < 8*roaringContainerWords {
+				return nil, ErrorInvalidFormat
+			}
+			for w := range roaringContainerWords {
+				x := binary.LittleEndian.Uint64(data[8*w:])
+				if x == 0 {
+					continue
+				}
+				if base+w*bitLength+bits.Len64(x) > size {
+					return nil, ErrorOutOfRange
+				}
+				b.v[base/bitLength+w] |= x
+			}
+			data = data[8*roaringContainerWords:]
+		default:
+			if len(data) < 2*card {
+				return nil, ErrorInvalidFormat
+			}
+			for i := range card {
+				pos := base + int(binary.LittleEndian.Uint16(data[2*i:]))
+				if pos >= size {
+					return nil, ErrorOutOfRange
+				}
+				b.Set1(pos)
+			}
+			data = data[2*card:]
+		}
+	}
+	return b.Build(), nil
+}
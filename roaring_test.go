@@ -0,0 +1,66 @@
+package bitvector
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestToRoaring(t *testing.T) {
+	b := NewBuilder(100)
+	b.SetPositions([]int{1, 2, 3})
+	// The bytes written by roaring.BitmapOf(1, 2, 3).ToBytes().
+	want := []byte{
+		0x3a, 0x30, 0, 0, 1, 0, 0, 0, // cookie and number of containers
+		0, 0, 2, 0, // key 0, cardinality 3
+		16, 0, 0, 0, // offset
+		1, 0, 2, 0, 3, 0,
+	}
+	if got, err := b.Build().ToRoaring(); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("ToRoaring() = % x, %v, want % x", got, err, want)
+	}
+}
+
+func TestRoaringRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, 1000, 200000} {
+		for _, density := range []float64{0, 0.01, 0.5, 1} {
+			want := randomDensity(size, density)
+			data, err := want.ToRoaring()
+			if err != nil {
+				t.Fatalf("ToRoaring(): %v", err)
+			}
+			got, err := FromRoaring(data, size)
+			if err != nil {
+				t.Fatalf("size %d, density %g: FromRoaring(): %v", size, density, err)
+			}
+			if !got.Equal(want) {
+				t.Errorf("size %d, density %g: FromRoaring(ToRoaring()) differs", size, density)
+			}
+		}
+	}
+
+	data, _ := randomDensity(1000, 0.5).ToRoaring()
+	if _, err := FromRoaring(data, 500); err != ErrorOutOfRange {
+		t.Errorf("FromRoaring() into a smaller size error = %v, want %v", err, ErrorOutOfRange)
+	}
+	if _, err := FromRoaring(data[:len(data)-1], 1000); err != ErrorInvalidFormat {
+		t.Errorf("FromRoaring() of truncated data error = %v, want %v", err, ErrorInvalidFormat)
+	}
+}
+
+func TestFromRoaringRuns(t *testing.T) {
+	// The bytes written by roaring.Bitmap.ToBytes() after RunOptimize() for
+	// the runs [10, 20) and [65536, 65546): two run containers without offsets.
+	data := []byte{
+		0x3b, 0x30, 1, 0, // cookie with 2 containers
+		0x03,       // both are run containers
+		0, 0, 9, 0, // key 0, cardinality 10
+		1, 0, 9, 0, // key 1, cardinality 10
+		1, 0, 10, 0, 9, 0, // one run from 10 of length 10
+		1, 0, 0, 0, 9, 0, // one run from 0 of length 10
+	}
+	want, _ := NewFromRuns([][2]int{{10, 10}, {65536, 10}}, 70000)
+	got, err := FromRoaring(data, 70000)
+	if err != nil || !got.Equal(want) {
+		t.Errorf("FromRoaring() = %v, %v, want the runs", got.Positions(), err)
+	}
+}
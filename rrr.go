@@ -0,0 +1,219 @@
+package bitvector
+
+import (
+	"math/bits"
+	"sort"
+)
+
+const (
+	// rrrBlockBits is the number of bits per block of RRRVector.
+	rrrBlockBits = 63
+	// rrrClassBits is the width of the class of a block, which is at most rrrBlockBits.
+	rrrClassBits = 6
+	// rrrSuperblockBlocks is the number of blocks between samples of the rank and offset position.
+	rrrSuperblockBlocks = 32
+)
+
+var (
+	// rrrBinomial[n][k] is the binomial coefficient n choose k.
+	rrrBinomial [rrrBlockBits + 1][rrrBlockBits + 1]uint64
+	// rrrOffsetBits[c] is the width of the offset of a block of class c.
+	rrrOffsetBits [rrrBlockBits + 1]uint
+)
+
+func init() {
+	for n := range rrrBinomial {
+		rrrBinomial[n][0] = 1
+		for k := 1; k <= n; k++ {
+			rrrBinomial[n][k] = rrrBinomial[n-1][k-1] + rrrBinomial[n-1][k]
+		}
+	}
+	for c := range rrrOffsetBits {
+		rrrOffsetBits[c] = uint(bits.Len64(rrrBinomial[rrrBlockBits][c] - 1))
+	}
+}
+
+// RRRVector is a bit vector compressed by the Raman-Raman-Rao encoding, which
+// takes space close to the zero-order entropy of the bits. Each block of
+// rrrBlockBits bits is stored as its class, the count of its 1s, and its offset,
+// the index of the block among those of the same class.
+type RRRVector struct {
+	size    int      // size of the bit vector.
+	ones    int      // the count of 1s.
+	classes []uint64 // the packed classes of the blocks.
+	offsets []uint64 // the packed offsets of the blocks, of rrrOffsetBits[class] bits each.
+	rank    []int    // the count of 1s before each superblock.
+	pos     []int    // the position in offsets of the first offset of each superblock.
+}
+
+var _ RankSelect = RRRVector{}
+
+// NewRRRVector makes an RRRVector of the bits of b.
+func NewRRRVector(b *BitVector) *RRRVector {
+	blocks := (b.size + rrrBlockBits - 1) / rrrBlockBits
+	r := &RRRVector{
+		size:    b.size,
+		classes: make([]uint64, blocks*rrrClassBits/bitLength+1),
+		rank:    make([]int, blocks/rrrSuperblockBlocks+1),
+		pos:     make([]int, blocks/rrrSuperblockBlocks+1),
+	}
+	var offsets []uint64
+	pos := 0
+	for k := range blocks {
+		if k%rrrSuperblockBlocks == 0 {
+			r.rank[k/rrrSuperblockBlocks], r.pos[k/rrrSuperblockBlocks] = r.ones, pos
+		}
+		x, _ := b.GetBits(k*rrrBlockBits, min(rrrBlockBits, b.size-k*rrrBlockBits))
+		c := bits.OnesCount64(x)
+		setBits(r.classes, k*rrrClassBits, rrrClassBits, uint64(c))
+		for len(offsets)*bitLength < pos+bitLength {
+			offsets = append(offsets, 0)
+		}
+		setBits(offsets, pos, rrrOffsetBits[c], rrrEncode(x, c))
+		r.ones += c
+		pos += int(rrrOffsetBits[c])
+	}
+	if blocks%rrrSuperblockBlocks == 0 {
+		r.rank[blocks/rrrSuperblockBlocks], r.pos[blocks/rrrSuperblockBlocks] = r.ones, pos
+	}
+	r.offsets = offsets[:(pos+bitLength-1)/bitLength]
+	return r
+}
+
+// rrrEncode returns the offset of the block x of class c: the sum of
+// (p choose j) over its j-th 1, counting from 1, at each position p.
+func rrrEncode(x uint64, c int) uint64 {
+	var offset uint64
+	for j := 1; x != 0; j++ {
+		offset += rrrBinomial[bits.TrailingZeros64(x)][j]
+		x &= x - 1
+	}
+	return offset
+}
+
+// rrrDecode returns the block of class c with the offset.
+func rrrDecode(offset uint64, c int) uint64 {
+	var x uint64
+	p := rrrBlockBits - 1
+	for j := c; j > 0; j-- {
+		for rrrBinomial[p][j] > offset {
+			p--
+		}
+		x |= 1 << uint(p)
+		offset -= rrrBinomial[p][j]
+		p--
+	}
+	return x
+}
+
+// Len returns the size of the bit vector.
+func (r RRRVector) Len() int {
+	return r.size
+}
+
+// SizeInBytes returns the number of bytes used by the encoded bits and the index.
+func (r RRRVector) SizeInBytes() int {
+	return 8 * (len(r.classes) + len(r.offsets) + len(r.rank) + len(r.pos))
+}
+
+// class returns the class of the k-th block.
+func (r RRRVector) class(k int) int {
+	return int(getBits(r.classes, k*rrrClassBits, rrrClassBits))
+}
+
+// block returns the k-th block and the count of 1s before it.
+func (r RRRVector) block(k int) (uint64, int) {
+	s := k / rrrSuperblockBlocks
+	rank, pos := r.rank[s], r.pos[s]
+	for j := s * rrrSuperblockBlocks; j < k; j++ {
+		c := r.class(j)
+		rank += c
+		pos += int(rrrOffsetBits[c])
+	}
+	c := r.class(k)
+	return rrrDecode(getBits(r.offsets, pos, rrrOffsetBits[c]), c), rank
+}
+
+// Get returns true or false, the value of the i-th bit.
+func (r RRRVector) Get(i int) (bool, error) {
+	if i < 0 || i >= r.size {
+		return false, ErrorOutOfRange
+	}
+	x, _ := r.block(i / rrrBlockBits)
+	return x>>uint(i%rrrBlockBits)&1 == 1, nil
+}
+
+// Rank1 returns the count of 1s before the i-th bit.
+func (r RRRVector) Rank1(i int) (int, error) {
+	if i < 0 || i > r.size {
+		return 0, ErrorOutOfRange
+	}
+	if i == r.size {
+		return r.ones, nil
+	}
+	x, rank := r.block(i / rrrBlockBits)
+	return rank + bits.OnesCount64(x & ^(maskFF<<uint(i%rrrBlockBits))), nil
+}
+
+// Rank0 returns the count of 0s before the i-th bit.
+func (r RRRVector) Rank0(i int) (int, error) {
+	val, err := r.Rank1(i)
+	if err != nil {
+		return 0, err
+	}
+	return i - val, nil
+}
+
+// CountOnes returns the count of 1s in the bit vector.
+func (r RRRVector) CountOnes() int {
+	return r.ones
+}
+
+// Select1 returns the index of the i-th 1. It returns ErrorOutOfRange for
+// a negative i and ErrorNotExist if there are not more than i 1s.
+func (r RRRVector) Select1(i int) (int, error) {
+	return r.selectBit(i, true)
+}
+
+// Select0 returns the index of the i-th 0. It returns ErrorOutOfRange for
+// a negative i and ErrorNotExist if there are not more than i 0s.
+func (r RRRVector) Select0(i int) (int, error) {
+	return r.selectBit(i, false)
+}
+
+// selectBit returns the index of the i-th 1, or 0 if x is false.
+func (r RRRVector) selectBit(i int, x bool) (int, error) {
+	count := func(rank, k int) int {
+		if x {
+			return rank
+		}
+		return k*rrrBlockBits - rank
+	}
+	total := r.ones
+	if !x {
+		total = r.size - r.ones
+	}
+	if i < 0 {
+		return 0, ErrorOutOfRange
+	}
+	if i >= total {
+		return 0, ErrorNotExist
+	}
+
+	supers := (r.size + rrrBlockBits*rrrSuperblockBlocks - 1) / (rrrBlockBits * rrrSuperblockBlocks)
+	s := sort.Search(supers, func(s int) bool { return count(r.rank[s], s*rrrSuperblockBlocks) > i }) - 1
+	k, rank := s*rrrSuperblockBlocks, r.rank[s]
+	for c := r.class(k); count(rank+c, k+1) <= i; c = r.class(k) {
+		rank += c
+		k++
+	}
+	w, _ := r.block(k)
+	if !x {
+		w = ^w
+	}
+	pos, err := selectInWord(w, i-count(rank, k))
+	if err != nil {
+		return 0, err
+	}
+	return k*rrrBlockBits + pos, nil
+}
@@ -0,0 +1,94 @@
+package bitvector
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+// sameRankSelect checks that every query on got answers as on want.
+func sameRankSelect(t *testing.T, got RankSelect, want *BitVector) {
+	t.Helper()
+	if got.Len() != want.Len() {
+		t.Fatalf("Len() = %d, want %d", got.Len(), want.Len())
+	}
+	for i := -1; i <= want.Len(); i++ {
+		g, gerr := got.Get(i)
+		w, werr := want.Get(i)
+		if g != w || gerr != werr {
+			t.Fatalf("Get(%d) = %v, %v, want %v, %v", i, g, gerr, w, werr)
+		}
+		gr, gerr := got.Rank1(i)
+		wr, werr := want.Rank1(i)
+		if gr != wr || gerr != werr {
+			t.Fatalf("Rank1(%d) = %d, %v, want %d, %v", i, gr, gerr, wr, werr)
+		}
+	}
+	for i := -1; i <= want.Len(); i++ {
+		g, gerr := got.Select1(i)
+		w, werr := want.Select1(i)
+		if g != w || gerr != werr {
+			t.Fatalf("Select1(%d) = %d, %v, want %d, %v", i, g, gerr, w, werr)
+		}
+		g, gerr = got.Select0(i)
+		w, werr = want.Select0(i)
+		if g != w || gerr != werr {
+			t.Fatalf("Select0(%d) = %d, %v, want %d, %v", i, g, gerr, w, werr)
+		}
+	}
+}
+
+func TestRRRVector(t *testing.T) {
+	for _, size := range []int{0, 1, 62, 63, 64, 2016, 2017, 5000} {
+		for _, density := range []float64{0, 0.01, 0.5, 1} {
+			want := randomDensity(size, density)
+			r := NewRRRVector(want)
+			sameRankSelect(t, r, want)
+		}
+	}
+}
+
+func TestRRRVectorCompresses(t *testing.T) {
+	const size = 1e6
+	want := randomDensity(size, 0.01)
+	r := NewRRRVector(want)
+	if r.SizeInBytes() >= want.SizeInBytes()/2 {
+		t.Errorf("SizeInBytes() = %d, want below half of %d", r.SizeInBytes(), want.SizeInBytes())
+	}
+	for range 1000 {
+		i := rand.Intn(size)
+		g, _ := r.Rank1(i)
+		w, _ := want.Rank1(i)
+		if g != w {
+			t.Fatalf("Rank1(%d) = %d, want %d", i, g, w)
+		}
+	}
+}
+
+func TestRRREncode(t *testing.T) {
+	for _, x := range []uint64{0, 1, 1 << 62, 1<<63 - 1, 0x5555555555555555 >> 1, rand.Uint64() >> 1} {
+		c := bits.OnesCount64(x)
+		offset := rrrEncode(x, c)
+		if offset >= rrrBinomial[rrrBlockBits][c] {
+			t.Errorf("rrrEncode(%#x) = %d, not below %d", x, offset, rrrBinomial[rrrBlockBits][c])
+		}
+		if got := rrrDecode(offset, c); got != x {
+			t.Errorf("rrrDecode(rrrEncode(%#x)) = %#x", x, got)
+		}
+	}
+}
+
+func BenchmarkRRRVector(b *testing.B) {
+	want := randomDensity(bigSize, 0.01)
+	r := NewRRRVector(want)
+	b.Run("Rank1", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			r.Rank1(rand.Intn(bigSize))
+		}
+	})
+	b.Run("Select1", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			r.Select1(rand.Intn(r.CountOnes()))
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package bitvector
+
+import "sort"
+
+// SDVector is a sparse bit vector holding the Elias-Fano encoding of the
+// positions of its 1s, which takes about 2 + log(size/ones) bits per 1.
+type SDVector struct {
+	size int        // size of the bit vector.
+	ef   *EliasFano // the positions of the 1s.
+}
+
+var _ RankSelect = SDVector{}
+
+// NewSDVector makes an SDVector of the specified size whose 1s are at positions,
+// which must be increasing. It returns ErrorNotSorted if they are not and
+// ErrorOutOfRange if one is out of [0, size).
+func NewSDVector(positions []int, size int) (*SDVector, error) {
+	values := make([]uint64, len(positions))
+	for j, pos := range positions {
+		if pos < 0 || pos >= size {
+			return nil, ErrorOutOfRange
+		}
+		if j > 0 && positions[j-1] >= pos {
+			return nil, ErrorNotSorted
+		}
+		values[j] = uint64(pos)
+	}
+	ef, err := NewEliasFano(values)
+	if err != nil {
+		return nil, err
+	}
+	return &SDVector{size: size, ef: ef}, nil
+}
+
+// Len returns the size of the bit vector.
+func (s SDVector) Len() int {
+	return s.size
+}
+
+// CountOnes returns the count of 1s in the bit vector.
+func (s SDVector) CountOnes() int {
+	return s.ef.Len()
+}
+
+// SizeInBytes returns the number of bytes used by the encoded positions.
+func (s SDVector) SizeInBytes() int {
+	return 8*len(s.ef.low) + s.ef.high.SizeInBytes()
+}
+
+// Get returns true or false, the value of the i-th bit.
+func (s SDVector) Get(i int) (bool, error) {
+	if i < 0 || i >= s.size {
+		return false, ErrorOutOfRange
+	}
+	rank, _ := s.Rank1(i)
+	pos, err := s.Select1(rank)
+	return err == nil && pos == i, nil
+}
+
+// Rank1 returns the count of 1s before the i-th bit.
+func (s SDVector) Rank1(i int) (int, error) {
+	if i < 0 || i > s.size {
+		return 0, ErrorOutOfRange
+	}
+	// The 1s whose high bits are below those of i come before the h-th 0 of
+	// the high bits, and those with the same high bits before the next 0.
+	h := i >> s.ef.lowBits
+	zeros := s.ef.high.Len() - s.ef.Len()
+	if h >= zeros {
+		return s.ef.Len(), nil
+	}
+	lo := 0
+	if h > 0 {
+		pos, _ := s.ef.high.Select0(h - 1)
+		lo = pos - (h - 1)
+	}
+	pos, _ := s.ef.high.Select0(h)
+	hi := pos - h
+	return lo + sort.Search(hi-lo, func(j int) bool {
+		x, _ := s.ef.Get(lo + j)
+		return x >= uint64(i)
+	}), nil
+}
+
+// Rank0 returns the count of 0s before the i-th bit.
+func (s SDVector) Rank0(i int) (int, error) {
+	val, err := s.Rank1(i)
+	if err != nil {
+		return 0, err
+	}
+	return i - val, nil
+}
+
+// Select1 returns the index of the i-th 1. It returns ErrorOutOfRange for
+// a negative i and ErrorNotExist if there are not more than i 1s.
+func (s SDVector) Select1(i int) (int, error) {
+	if i < 0 {
+		return 0, ErrorOutOfRange
+	}
+	if i >= s.ef.Len() {
+		return 0, ErrorNotExist
+	}
+	x, err := s.ef.Get(i)
+	return int(x), err
+}
+
+// Select0 returns the index of the i-th 0. It returns ErrorOutOfRange for
+// a negative i and ErrorNotExist if there are not more than i 0s.
+func (s SDVector) Select0(i int) (int, error) {
+	if i < 0 {
+		return 0, ErrorOutOfRange
+	}
+	if i >= s.size-s.ef.Len() {
+		return 0, ErrorNotExist
+	}
+	// The i-th 0 follows the first j 1s for the least j such that
+	// more than i 0s come before the j-th 1.
+	j := sort.Search(s.ef.Len(), func(j int) bool {
+		x, _ := s.ef.Get(j)
+		return int(x)-j > i
+	})
+	return i + j, nil
+}
@@ -0,0 +1,51 @@
+package bitvector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSDVector(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 1000, 5000} {
+		for _, density := range []float64{0, 0.01, 0.3, 1} {
+			want := randomDensity(size, density)
+			s, err := NewSDVector(want.Positions(), size)
+			if err != nil {
+				t.Fatalf("NewSDVector(): %v", err)
+			}
+			sameRankSelect(t, s, want)
+		}
+	}
+
+	if _, err := NewSDVector([]int{3, 3}, 10); err != ErrorNotSorted {
+		t.Errorf("NewSDVector() of repeated positions error = %v, want %v", err, ErrorNotSorted)
+	}
+	if _, err := NewSDVector([]int{3, 10}, 10); err != ErrorOutOfRange {
+		t.Errorf("NewSDVector() of a position beyond the size error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
+
+func TestSDVectorSize(t *testing.T) {
+	const size = 1000000
+	want := randomDensity(size, 0.01)
+	s, _ := NewSDVector(want.Positions(), size)
+	// Compare with the words alone, without the positions kept by a sparse BitVector.
+	if words := 8 * (size/bitLength + 1); s.SizeInBytes()*8 > words {
+		t.Errorf("SizeInBytes() = %d, want at most an eighth of %d", s.SizeInBytes(), words)
+	}
+}
+
+func BenchmarkSDVector(b *testing.B) {
+	want := randomDensity(bigSize, 0.01)
+	s, _ := NewSDVector(want.Positions(), bigSize)
+	b.Run("Rank1", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s.Rank1(rand.Intn(bigSize))
+		}
+	})
+	b.Run("Select1", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s.Select1(rand.Intn(s.CountOnes()))
+		}
+	})
+}
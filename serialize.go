@@ -0,0 +1,395 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"slices"
+	"unsafe"
+)
+
+// The binary form of a BitVector is a header followed by sections of
+// little-endian uint64s, so that it can be used in place once mapped into memory:
+//
+//	magic   [4]byte "SBV" and the version
+//	flags   uint32
+//	size    uint64
+//	words   uint64, then the words of the bit vector
+//	ranks   uint64, then the rank entries
+//	blocks  uint64, then the counts within superblocks as uint16s, four to a word, if flagBlocks is set
+//	rank9   uint64, then the rank9 index, if flagRank9 is set
+//	samples uint64, then the select index, if flagSelectIndex is set
+//	ones    uint64, then the positions of the 1s, if flagOnes is set
+//	zeros   uint64, then the select index over 0s, if flagSelect0Index is set
+//
+// The words and ranks sections are empty if flagAllZeros or flagAllOnes is set,
+// the ranks section is empty if flagRank9 or flagInterleaved is set, and otherwise
+// it has an entry per superblock if flagBlocks or flagGrouped is set.
+// Files without any of these flags, written by older releases, have an entry per word.
+// The counts interleaved with the words under flagInterleaved are not stored
+// but recounted by the decoder, which then copies the words.
+const (
+	formatVersion    = 1
+	headerSize       = 16
+	flagSelectIndex  = 1 << 0
+	flagOnes         = 1 << 1
+	flagAllZeros     = 1 << 2
+	flagAllOnes      = 1 << 3
+	flagGrouped      = 1 << 4
+	flagSelect0Index = 1 << 5
+	flagBlocks       = 1 << 6
+	flagRank9        = 1 << 7
+	flagInterleaved  = 1 << 8
+)
+
+var formatMagic = [3]byte{'S', 'B', 'V'}
+
+// canAlias reports whether the sections of the binary form can be used in place as []uint64 and []int.
+var canAlias = binary.NativeEndian.Uint16([]byte{1, 0}) == 1 && bits.UintSize == 64
+
+// MarshalBinary encodes the bit vector and its index into binary form.
+func (b BitVector) MarshalBinary() ([]byte, error) {
+	words := 0
+	if b.store != nil {
+		words = b.store.Len()
+	}
+	n := headerSize + 8*(1+words) + 8*(1+len(b.rank))
+	var flags uint32
+	if b.select1 != nil {
+		flags |= flagSelectIndex
+		n += 8 * (1 + len(b.select1))
+	}
+	if b.ones != nil {
+		flags |= flagOnes
+		n += 8 * (1 + len(b.ones))
+	}
+	if b.select0 != nil {
+		flags |= flagSelect0Index
+		n += 8 * (1 + len(b.select0))
+	}
+	if b.constant && b.fill == 0 {
+		flags |= flagAllZeros
+	} else if b.constant {
+		flags |= flagAllOnes
+	}
+	if b.grouped {
+		flags |= flagGrouped
+	}
+	if b.block != nil {
+		flags |= flagBlocks
+		n += 8 * (1 + (len(b.block)+3)/4)
+	}
+	if b.rank9 != nil {
+		flags |= flagRank9
+		n += 8 * (1 + len(b.rank9))
+	}
+	if _, ok := b.store.(lineStore); ok {
+		flags |= flagInterleaved
+	}
+
+	data := make([]byte, 0, n)
+	data = append(data, formatMagic[:]...)
+	data = append(data, formatVersion)
+	data = binary.LittleEndian.AppendUint32(data, flags)
+	data = binary.LittleEndian.AppendUint64(data, uint64(b.size))
+	data = binary.LittleEndian.AppendUint64(data, uint64(words))
+	for k := range words {
+		data = binary.LittleEndian.AppendUint64(data, b.at(k))
+	}
+	data = appendInts(data, b.rank)
+	if b.block != nil {
+		data = binary.LittleEndian.AppendUint64(data, uint64((len(b.block)+3)/4))
+		for _, x := range b.block {
+			data = binary.LittleEndian.AppendUint16(data, x)
+		}
+		data = append(data, make([]byte, (8-len(data)%8)%8)...)
+	}
+	if b.rank9 != nil {
+		data = binary.LittleEndian.AppendUint64(data, uint64(len(b.rank9)))
+		for _, x := range b.rank9 {
+			data = binary.LittleEndian.AppendUint64(data, x)
+		}
+	}
+	if b.select1 != nil {
+		data = appendInts(data, b.select1)
+	}
+	if b.ones != nil {
+		data = appendInts(data, b.ones)
+	}
+	if b.select0 != nil {
+		data = appendInts(data, b.select0)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes the binary form made by MarshalBinary.
+func (b *BitVector) UnmarshalBinary(data []byte) error {
+	v, err := decode(data, false)
+	if err != nil {
+		return err
+	}
+	*b = *v
+	return nil
+}
+
+// MarshalDataOnly encodes just the size and the bits of the bit vector, which is
+// the most compact form: the size as a little-endian uint64 followed by
+// ByteLen() bytes of bits in LSBFirst order.
+// UnmarshalDataOnly rebuilds the index when decoding.
+func (b BitVector) MarshalDataOnly() []byte {
+	data := make([]byte, 8, 8+b.ByteLen())
+	binary.LittleEndian.PutUint64(data, uint64(b.size))
+	return append(data, b.Bytes(LSBFirst)...)
+}
+
+// UnmarshalDataOnly decodes the form made by MarshalDataOnly and builds the bit vector.
+func UnmarshalDataOnly(data []byte) (*BitVector, error) {
+	if len(data) < 8 {
+		return nil, ErrorInvalidFormat
+	}
+	size := binary.LittleEndian.Uint64(data)
+	if size > uint64(maxInt) || uint64(len(data)-8) != (size+7)/8 {
+		return nil, ErrorInvalidFormat
+	}
+	b := NewBuilder(int(size))
+	b.OrBytes(data[8:], 0)
+	return b.Build(), nil
+}
+
+func appendInts(data []byte, xs []int) []byte {
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(xs)))
+	for _, x := range xs {
+		data = binary.LittleEndian.AppendUint64(data, uint64(x))
+	}
+	return data
+}
+
+// decode decodes the binary form in data. If alias is true and the platform
+// allows it, the returned bit vector refers to data instead of copying it.
+func decode(data []byte, alias bool) (*BitVector, error) {
+	if len(data) < headerSize+8 || [3]byte(data[:3]) != formatMagic || data[3] != formatVersion {
+		return nil, ErrorInvalidFormat
+	}
+	flags := binary.LittleEndian.Uint32(data[4:])
+	size := binary.LittleEndian.Uint64(data[8:])
+	if size > uint64(maxInt) {
+		return nil, ErrorInvalidFormat
+	}
+	d := decoder{data: data[headerSize:], alias: alias && canAlias}
+
+	b := &BitVector{size: int(size)}
+	words := d.words()
+	b.rank = d.ints()
+	if flags&flagBlocks != 0 {
+		b.block = d.uint16s(len(words))
+	}
+	if flags&flagRank9 != 0 {
+		b.rank9 = d.words()
+	}
+	if flags&flagSelectIndex != 0 {
+		b.select1 = d.ints()
+		if b.select1 == nil {
+			b.select1 = []int{}
+		}
+	}
+	if flags&flagOnes != 0 {
+		b.ones = d.ints()
+		if b.ones == nil {
+			b.ones = []int{}
+		}
+	}
+	if flags&flagSelect0Index != 0 {
+		b.select0 = d.ints()
+		if b.select0 == nil {
+			b.select0 = []int{}
+		}
+	}
+	if flags&(flagAllZeros|flagAllOnes) != 0 {
+		b.constant = true
+		if flags&flagAllOnes != 0 {
+			b.fill = maskFF
+		}
+		if d.err || flags&flagAllZeros != 0 && flags&flagAllOnes != 0 || words != nil || b.rank != nil || b.rank9 != nil {
+			return nil, ErrorInvalidFormat
+		}
+		return b, nil
+	}
+	ranks := len(words)
+	if flags&flagGrouped != 0 {
+		b.grouped = true
+		ranks = (len(words) + groupWords - 1) / groupWords
+	}
+	if flags&flagBlocks != 0 {
+		if b.grouped {
+			return nil, ErrorInvalidFormat
+		}
+		ranks = (len(words) + superblockWords - 1) / superblockWords
+	}
+	if flags&flagRank9 != 0 {
+		if b.grouped || b.block != nil || len(b.rank9) != 2*((len(words)+superblockWords-1)/superblockWords) {
+			return nil, ErrorInvalidFormat
+		}
+		ranks = 0
+	}
+	if flags&flagInterleaved != 0 {
+		if b.grouped || b.block != nil || b.rank9 != nil {
+			return nil, ErrorInvalidFormat
+		}
+		ranks = 0
+	}
+	if d.err || len(words) != b.size/bitLength+1 || len(b.rank) != ranks {
+		return nil, ErrorInvalidFormat
+	}
+	b.store = sliceStore(words)
+	if flags&flagInterleaved != 0 {
+		b.store = newLineStore(words)
+	} else if !b.validRanks(words) {
+		return nil, ErrorInvalidFormat
+	}
+	if !b.validSelects() {
+		return nil, ErrorInvalidFormat
+	}
+	return b, nil
+}
+
+// validRanks returns whether the rank index of a decoded bit vector counts the
+// 1s of its words, as Rank1 and the select searches over it trust it to.
+func (b BitVector) validRanks(words []uint64) bool {
+	if b.rank9 != nil {
+		return slices.Equal(b.rank9, rank9Index(words))
+	}
+	rank := 0
+	for k, x := range words {
+		switch {
+		case b.block == nil && !b.grouped:
+			if b.rank[k] != rank {
+				return false
+			}
+		case k%superblockWords == 0:
+			if b.rank[k/superblockWords] != rank {
+				return false
+			}
+		}
+		if b.block != nil && int(b.block[k]) != rank-b.rank[k/superblockWords] {
+			return false
+		}
+		rank += bits.OnesCount64(x)
+	}
+	return true
+}
+
+// validSelects returns whether the positions of the 1s and the select
+// samples of a decoded bit vector are those Build would keep, so that a
+// corrupt file cannot send a later Select1 or Select0 out of the vector.
+func (b BitVector) validSelects() bool {
+	ones := b.CountOnes()
+	if b.ones != nil {
+		if len(b.ones) != ones {
+			return false
+		}
+		for i, pos := range b.ones {
+			if pos < 0 || pos >= b.size || i > 0 && pos <= b.ones[i-1] {
+				return false
+			}
+			if x, _ := b.Get(pos); !x {
+				return false
+			}
+		}
+	}
+	valid := func(samples []int, count int, x bool) bool {
+		if samples == nil {
+			return true
+		}
+		if len(samples) != (count+selectSampleRate-1)/selectSampleRate {
+			return false
+		}
+		for k, pos := range samples {
+			if pos < 0 || pos >= b.size {
+				return false
+			}
+			rank, _ := b.Rank1(pos)
+			if !x {
+				rank = pos - rank
+			}
+			if bit, _ := b.Get(pos); bit != x || rank != k*selectSampleRate {
+				return false
+			}
+		}
+		return true
+	}
+	return valid(b.select1, ones, true) && valid(b.select0, b.size-ones, false)
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+// decoder reads the length-prefixed sections of the binary form.
+type decoder struct {
+	data  []byte
+	alias bool // whether sections are used in place.
+	err   bool // whether the data ended early.
+}
+
+func (d *decoder) section() []byte {
+	if d.err || len(d.data) < 8 {
+		d.err = true
+		return nil
+	}
+	n := binary.LittleEndian.Uint64(d.data)
+	d.data = d.data[8:]
+	if n > uint64(len(d.data)/8) {
+		d.err = true
+		return nil
+	}
+	s := d.data[:8*n]
+	d.data = d.data[8*n:]
+	return s
+}
+
+func (d *decoder) words() []uint64 {
+	s := d.section()
+	if len(s) == 0 {
+		return nil
+	}
+	if d.alias {
+		return unsafe.Slice((*uint64)(unsafe.Pointer(&s[0])), len(s)/8)
+	}
+	v := make([]uint64, len(s)/8)
+	for i := range v {
+		v[i] = binary.LittleEndian.Uint64(s[8*i:])
+	}
+	return v
+}
+
+// uint16s reads a section of n uint16s, four to a word.
+func (d *decoder) uint16s(n int) []uint16 {
+	s := d.section()
+	if len(s) != 8*((n+3)/4) {
+		d.err = true
+		return nil
+	}
+	if n == 0 {
+		return []uint16{}
+	}
+	if d.alias {
+		return unsafe.Slice((*uint16)(unsafe.Pointer(&s[0])), n)
+	}
+	v := make([]uint16, n)
+	for i := range v {
+		v[i] = binary.LittleEndian.Uint16(s[2*i:])
+	}
+	return v
+}
+
+func (d *decoder) ints() []int {
+	s := d.section()
+	if len(s) == 0 {
+		return nil
+	}
+	if d.alias {
+		return unsafe.Slice((*int)(unsafe.Pointer(&s[0])), len(s)/8)
+	}
+	v := make([]int, len(s)/8)
+	for i := range v {
+		v[i] = int(binary.LittleEndian.Uint64(s[8*i:]))
+	}
+	return v
+}
@@ -0,0 +1,265 @@
+package bitvector
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func sameQueries(t *testing.T, got, want *BitVector) {
+	t.Helper()
+	if got.Len() != want.Len() || got.HasSelectIndex() != want.HasSelectIndex() {
+		t.Fatalf("Len(), HasSelectIndex() = %d, %v, want %d, %v",
+			got.Len(), got.HasSelectIndex(), want.Len(), want.HasSelectIndex())
+	}
+	for i := 0; i <= want.Len(); i++ {
+		g, _ := got.Rank1(i)
+		w, _ := want.Rank1(i)
+		if g != w {
+			t.Fatalf("Rank1(%d) = %d, want %d", i, g, w)
+		}
+	}
+	for i := 0; i < want.CountOnes(); i++ {
+		g, _ := got.Select1(i)
+		w, _ := want.Select1(i)
+		if g != w {
+			t.Fatalf("Select1(%d) = %d, want %d", i, g, w)
+		}
+	}
+}
+
+func testVectors() []*BitVector {
+	var vs []*BitVector
+	for _, size := range []int{0, 1, 64, 1000, 5000} {
+		s, bv := random(size)
+		vs = append(vs, bv)
+		b := NewBuilder(size)
+		for i, c := range s {
+			b.Set(i, c == '1')
+		}
+		vs = append(vs, b.BuildWithSelectIndex(true))
+	}
+	return append(vs, randomDensity(5000, 0.001), randomDensity(100000, 0.001))
+}
+
+func TestMarshalBinary(t *testing.T) {
+	for _, bv := range testVectors() {
+		data, err := bv.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary(): %v", err)
+		}
+		var got BitVector
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary(): %v", err)
+		}
+		sameQueries(t, &got, bv)
+
+		for _, n := range []int{0, 3, headerSize, len(data) - 1} {
+			if err := got.UnmarshalBinary(data[:n]); err != ErrorInvalidFormat {
+				t.Errorf("UnmarshalBinary() of %d of %d bytes error = %v, want %v", n, len(data), err, ErrorInvalidFormat)
+			}
+		}
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	for i, bv := range testVectors() {
+		path := filepath.Join(dir, "bv")
+		data, _ := bv.MarshalBinary()
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, closer, err := OpenFile(path)
+		if err != nil {
+			t.Fatalf("OpenFile() #%d: %v", i, err)
+		}
+		sameQueries(t, got, bv)
+		if err := closer(); err != nil {
+			t.Errorf("closer(): %v", err)
+		}
+	}
+
+	if _, _, err := OpenFile(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("OpenFile() of a missing file succeeded")
+	}
+}
+
+func TestMarshalDataOnly(t *testing.T) {
+	for _, bv := range testVectors() {
+		data := bv.MarshalDataOnly()
+		if want := (bv.Len()+7)/8 + 8; len(data) != want {
+			t.Errorf("len(MarshalDataOnly()) of size %d = %d, want %d", bv.Len(), len(data), want)
+		}
+		got, err := UnmarshalDataOnly(data)
+		if err != nil {
+			t.Fatalf("UnmarshalDataOnly(): %v", err)
+		}
+		if !got.Equal(bv) {
+			t.Errorf("UnmarshalDataOnly() of size %d differs", bv.Len())
+		}
+		for i := 0; i <= bv.Len(); i++ {
+			g, _ := got.Rank1(i)
+			w, _ := bv.Rank1(i)
+			if g != w {
+				t.Fatalf("Rank1(%d) = %d, want %d", i, g, w)
+			}
+		}
+
+		for _, n := range []int{0, 7, len(data) - 1} {
+			if _, err := UnmarshalDataOnly(data[:n]); err != ErrorInvalidFormat {
+				t.Errorf("UnmarshalDataOnly() of %d of %d bytes error = %v, want %v", n, len(data), err, ErrorInvalidFormat)
+			}
+		}
+	}
+}
+
+func TestBuildAndMarshal(t *testing.T) {
+	for _, size := range []int{0, 100, 5000} {
+		s, _ := random(size)
+		b := NewBuilder(size)
+		for i, c := range s {
+			b.Set(i, c == '1')
+		}
+		bv, data, err := b.BuildAndMarshal()
+		if err != nil {
+			t.Fatalf("BuildAndMarshal(): %v", err)
+		}
+		var got BitVector
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary(): %v", err)
+		}
+		if !got.Equal(bv) {
+			t.Errorf("size %d: decoded vector differs from the built one", size)
+		}
+		sameQueries(t, &got, bv)
+	}
+}
+
+func TestSanitizeTail(t *testing.T) {
+	s, want := random(1000)
+	b := NewBuilder(len(s))
+	for i, c := range s {
+		b.Set(i, c == '1')
+	}
+	tail := uint(1000 % bitLength)
+	b.v[len(b.v)-1] |= maskFF << tail // garbage beyond the size
+	got := b.Build()
+	// A writer that counted the garbage gives a wrong last rank, which decode
+	// rejects but a bit vector made in memory can still hold.
+	words := 1000/bitLength + 1
+	got.rank[len(got.rank)-1] += 7
+	if got.CountOnes() == want.CountOnes() {
+		t.Fatalf("CountOnes() of the corrupt vector is already correct")
+	}
+
+	got.SanitizeTail()
+	if got.CountOnes() != want.CountOnes() {
+		t.Errorf("CountOnes() after SanitizeTail = %d, want %d", got.CountOnes(), want.CountOnes())
+	}
+	if x := got.at(words-1) >> tail; x != 0 {
+		t.Errorf("SanitizeTail left the bits %#x beyond the size", x)
+	}
+	sameQueries(t, got, want)
+}
+
+func TestUnmarshalCorruptSelects(t *testing.T) {
+	sparse := randomDensity(100000, 0.001)
+	s, _ := random(5000)
+	b := NewBuilder(len(s))
+	for i, c := range s {
+		b.Set(i, c == '1')
+	}
+	dense := b.BuildWithSelectIndex(true)
+	if sparse.ones == nil || dense.select1 == nil {
+		t.Fatalf("the test vectors have no positions of 1s or no select index")
+	}
+
+	// lastEntry returns the offset of the last entry of a section followed by
+	// the sections of the slices in after.
+	lastEntry := func(data []byte, after ...[]int) int {
+		end := len(data)
+		for _, ys := range after {
+			if ys != nil {
+				end -= 8 * (1 + len(ys))
+			}
+		}
+		return end - 8
+	}
+	for _, c := range []struct {
+		name string
+		bv   *BitVector
+		last func(data []byte) int
+		x    func(old uint64) uint64
+	}{
+		{"ones beyond the size", sparse, func(data []byte) int {
+			return lastEntry(data, sparse.select0)
+		}, func(uint64) uint64 { return 100000 }},
+		{"ones out of order", sparse, func(data []byte) int {
+			return lastEntry(data, sparse.select0)
+		}, func(uint64) uint64 { return 0 }},
+		{"select1 beyond the size", dense, func(data []byte) int {
+			return lastEntry(data, dense.ones, dense.select0)
+		}, func(uint64) uint64 { return 1 << 40 }},
+		{"select1 at a wrong 1", dense, func(data []byte) int {
+			return lastEntry(data, dense.ones, dense.select0)
+		}, func(old uint64) uint64 { return old - 1 }},
+		{"negative select1", dense, func(data []byte) int {
+			return lastEntry(data, dense.ones, dense.select0)
+		}, func(uint64) uint64 { return 1 << 63 }},
+	} {
+		data, _ := c.bv.MarshalBinary()
+		last := c.last(data)
+		binary.LittleEndian.PutUint64(data[last:], c.x(binary.LittleEndian.Uint64(data[last:])))
+		var got BitVector
+		if err := got.UnmarshalBinary(data); err != ErrorInvalidFormat {
+			t.Errorf("%s: UnmarshalBinary() error = %v, want %v", c.name, err, ErrorInvalidFormat)
+		}
+
+		path := filepath.Join(t.TempDir(), "bv")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := OpenFile(path); err != ErrorInvalidFormat {
+			t.Errorf("%s: OpenFile() error = %v, want %v", c.name, err, ErrorInvalidFormat)
+		}
+	}
+}
+
+func TestUnmarshalCorruptRanks(t *testing.T) {
+	// A plain rank index claiming 100 1s over words holding 4.
+	data := append(formatMagic[:], formatVersion)
+	data = binary.LittleEndian.AppendUint32(data, 0)
+	data = binary.LittleEndian.AppendUint64(data, 128)
+	data = appendInts(data, []int{0xF, 0, 0})
+	data = appendInts(data, []int{0, 100, 100})
+	var got BitVector
+	if err := got.UnmarshalBinary(data); err != ErrorInvalidFormat {
+		t.Errorf("UnmarshalBinary() of forged ranks error = %v, want %v", err, ErrorInvalidFormat)
+	}
+
+	s, _ := random(5000)
+	for name, build := range map[string]func(*Builder) *BitVector{
+		"Build":             (*Builder).Build,
+		"BuildSIMDFriendly": (*Builder).BuildSIMDFriendly,
+		"BuildRank9":        (*Builder).BuildRank9,
+	} {
+		b := NewBuilder(len(s))
+		for i, c := range s {
+			b.Set(i, c == '1')
+		}
+		bv := build(b)
+		data, _ := bv.MarshalBinary()
+		// Add one to the count of a word of the index, right after the words.
+		offset := headerSize + 8*(1+bv.numWords()) + 8
+		if bv.rank9 != nil {
+			offset += 8
+		}
+		binary.LittleEndian.PutUint64(data[offset:], binary.LittleEndian.Uint64(data[offset:])+1)
+		if err := got.UnmarshalBinary(data); err != ErrorInvalidFormat {
+			t.Errorf("%s: UnmarshalBinary() of a corrupt index error = %v, want %v", name, err, ErrorInvalidFormat)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package bitvector
+
+// TaggedBitVector is a bit vector with a payload for each 1, which makes a
+// compact sparse map keyed by position.
+type TaggedBitVector[T any] struct {
+	b      *BitVector
+	values []T // the payloads of the 1s, in the order of their positions.
+}
+
+// NewTaggedBitVector makes a tagged bit vector over b whose payloads are zero values.
+func NewTaggedBitVector[T any](b *BitVector) *TaggedBitVector[T] {
+	return &TaggedBitVector[T]{
+		b:      b,
+		values: make([]T, b.CountOnes()),
+	}
+}
+
+// BitVector returns the underlying bit vector.
+func (t TaggedBitVector[T]) BitVector() *BitVector {
+	return t.b
+}
+
+// SetPayload sets the payload of the 1 at pos.
+func (t *TaggedBitVector[T]) SetPayload(pos int, value T) error {
+	k, err := t.index(pos)
+	if err != nil {
+		return err
+	}
+	t.values[k] = value
+	return nil
+}
+
+// Value returns the payload of the 1 at pos, or false if the pos-th bit is not 1.
+func (t TaggedBitVector[T]) Value(pos int) (T, bool) {
+	k, err := t.index(pos)
+	if err != nil {
+		var zero T
+		return zero, false
+	}
+	return t.values[k], true
+}
+
+// index returns the index of the payload of the 1 at pos.
+func (t TaggedBitVector[T]) index(pos int) (int, error) {
+	if pos < 0 || pos >= t.b.Len() {
+		return 0, ErrorOutOfRange
+	}
+	k, isOne, _ := t.b.NavigateDown(pos)
+	if !isOne {
+		return 0, ErrorNotExist
+	}
+	return k, nil
+}
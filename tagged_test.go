@@ -0,0 +1,30 @@
+package bitvector
+
+import "testing"
+
+func TestTaggedBitVector(t *testing.T) {
+	want := map[int]string{3: "three", 64: "sixty-four", 65: "", 999: "last"}
+	b := NewBuilder(1000)
+	for pos := range want {
+		b.Set1(pos)
+	}
+	tagged := NewTaggedBitVector[string](b.Build())
+	for pos, s := range want {
+		if err := tagged.SetPayload(pos, s); err != nil {
+			t.Fatalf("SetPayload(%d) error = %v", pos, err)
+		}
+	}
+	if err := tagged.SetPayload(4, "four"); err != ErrorNotExist {
+		t.Errorf("SetPayload() on a 0 error = %v, want %v", err, ErrorNotExist)
+	}
+	if err := tagged.SetPayload(1000, "out"); err != ErrorOutOfRange {
+		t.Errorf("SetPayload() out of range error = %v, want %v", err, ErrorOutOfRange)
+	}
+
+	for pos := -1; pos <= 1000; pos++ {
+		s, ok := want[pos]
+		if got, gotOK := tagged.Value(pos); got != s || gotOK != ok {
+			t.Errorf("Value(%d) = %q, %v, want %q, %v", pos, got, gotOK, s, ok)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package bitvector
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// BuildFromScanner builds a BitVector from the tokens of sc, one bit per token.
+// A token is "0", "1", "false" or "true"; empty tokens are skipped.
+func BuildFromScanner(sc *bufio.Scanner) (*BitVector, error) {
+	var v []uint64
+	size := 0
+	for sc.Scan() {
+		var x uint64
+		switch strings.TrimSpace(sc.Text()) {
+		case "":
+			continue
+		case "0", "false":
+		case "1", "true":
+			x = 1
+		default:
+			return nil, ErrorInvalidFormat
+		}
+		if size%bitLength == 0 {
+			v = append(v, 0)
+		}
+		v[size/bitLength] |= x << uint(size%bitLength)
+		size++
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	b := NewBuilder(size)
+	copy(b.v, v)
+	return b.Build(), nil
+}
+
+// WriteASCII writes the bits to w as rows of width '0's and '1's, each
+// followed by a newline, the last of which may be shorter.
+func (b BitVector) WriteASCII(w io.Writer, width int) error {
+	if width <= 0 {
+		return ErrorOutOfRange
+	}
+	bw := bufio.NewWriter(w)
+	for i := 0; i < b.size; i++ {
+		c := byte('0')
+		if b.word(i/bitLength)>>uint(i%bitLength)&1 == 1 {
+			c = '1'
+		}
+		bw.WriteByte(c)
+		if (i+1)%width == 0 || i == b.size-1 {
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadASCII builds a BitVector from '0's and '1's read from r, such as
+// written by WriteASCII. Line breaks and other white space are skipped.
+func ReadASCII(r io.Reader) (*BitVector, error) {
+	sc := bufio.NewScanner(r)
+	sc.Split(bufio.ScanRunes)
+	return BuildFromScanner(sc)
+}
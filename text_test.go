@@ -0,0 +1,67 @@
+package bitvector
+
+import (
+	"bufio"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuildFromScanner(t *testing.T) {
+	input := "1\n0\n\ntrue\nfalse\n  1  \n" + strings.Repeat("0\n", 70) + "1\n"
+	bv, err := BuildFromScanner(bufio.NewScanner(strings.NewReader(input)))
+	if err != nil {
+		t.Fatalf("BuildFromScanner(): %v", err)
+	}
+	if bv.Len() != 76 {
+		t.Errorf("Len() = %d, want 76", bv.Len())
+	}
+	if got, want := bv.Positions(), []int{0, 2, 4, 75}; !slices.Equal(got, want) {
+		t.Errorf("Positions() = %v, want %v", got, want)
+	}
+
+	words := bufio.NewScanner(strings.NewReader("1 1 0 true"))
+	words.Split(bufio.ScanWords)
+	if bv, err := BuildFromScanner(words); err != nil || !slices.Equal(bv.Positions(), []int{0, 1, 3}) {
+		t.Errorf("BuildFromScanner() of words = %v, %v", bv, err)
+	}
+
+	bad := bufio.NewScanner(strings.NewReader("1\n2\n"))
+	if _, err := BuildFromScanner(bad); err != ErrorInvalidFormat {
+		t.Errorf("BuildFromScanner() of a bad token error = %v, want %v", err, ErrorInvalidFormat)
+	}
+}
+
+func TestWriteASCII(t *testing.T) {
+	b := NewBuilder(10)
+	b.SetPositions([]int{0, 3, 4, 9})
+	var sb strings.Builder
+	if err := b.Build().WriteASCII(&sb, 4); err != nil {
+		t.Fatalf("WriteASCII(): %v", err)
+	}
+	if got, want := sb.String(), "1001\n1000\n01\n"; got != want {
+		t.Errorf("WriteASCII() = %q, want %q", got, want)
+	}
+
+	for _, size := range []int{0, 1, 64, 1000} {
+		_, bv := random(size)
+		sb.Reset()
+		if err := bv.WriteASCII(&sb, 7); err != nil {
+			t.Fatalf("WriteASCII(): %v", err)
+		}
+		got, err := ReadASCII(strings.NewReader(sb.String()))
+		if err != nil {
+			t.Fatalf("ReadASCII(): %v", err)
+		}
+		if got.Len() != size || !got.Equal(bv) {
+			t.Errorf("size %d: ReadASCII() of WriteASCII() differs", size)
+		}
+	}
+
+	if err := NewBuilder(1).Build().WriteASCII(&sb, 0); err != ErrorOutOfRange {
+		t.Errorf("WriteASCII() of width 0 error = %v, want %v", err, ErrorOutOfRange)
+	}
+	if _, err := ReadASCII(strings.NewReader("01\n2\n")); err != ErrorInvalidFormat {
+		t.Errorf("ReadASCII() of a bad character error = %v, want %v", err, ErrorInvalidFormat)
+	}
+}
@@ -0,0 +1,49 @@
+package bitvector
+
+// TrackingBuilder is a builder of BitVector that also records which positions
+// were written, to catch positions that are never initialized.
+type TrackingBuilder struct {
+	b       *Builder
+	written *Builder
+	built   *BitVector // the written positions, once built.
+}
+
+// NewTrackingBuilder makes a new tracking builder of BitVector of the specified size.
+func NewTrackingBuilder(size int) *TrackingBuilder {
+	return &TrackingBuilder{
+		b:       NewBuilder(size),
+		written: NewBuilder(size),
+	}
+}
+
+// Len returns the size of the bit vector.
+func (t TrackingBuilder) Len() int {
+	return t.b.Len()
+}
+
+// Set sets i-th bit in the bit vector to v and records it as written.
+func (t *TrackingBuilder) Set(i int, v bool) {
+	t.b.Set(i, v)
+	t.written.Set1(i)
+}
+
+// Build builds the BitVector and the BitVector of the written positions.
+func (t *TrackingBuilder) Build() (bits, written *BitVector) {
+	bits = t.b.Build()
+	t.built = t.written.Build()
+	return bits, t.built
+}
+
+// Written returns the BitVector of the written positions, or nil before Build.
+func (t TrackingBuilder) Written() *BitVector {
+	return t.built
+}
+
+// WasSet returns whether the i-th bit was passed to Set, to 0 or 1.
+func (t TrackingBuilder) WasSet(i int) bool {
+	if t.built == nil {
+		return t.written.Get(i)
+	}
+	set, _ := t.built.Get(i)
+	return set
+}
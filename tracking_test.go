@@ -0,0 +1,30 @@
+package bitvector
+
+import "testing"
+
+func TestTrackingBuilder(t *testing.T) {
+	b := NewTrackingBuilder(100)
+	b.Set(3, true)
+	b.Set(4, false)
+	b.Set(70, true)
+	b.Set(70, false)
+	if !b.WasSet(4) || b.WasSet(5) {
+		t.Errorf("WasSet(4), WasSet(5) before Build = %v, %v, want true, false", b.WasSet(4), b.WasSet(5))
+	}
+	if b.Written() != nil {
+		t.Errorf("Written() before Build is not nil")
+	}
+
+	bits, written := b.Build()
+	if got := bits.Positions(); len(got) != 1 || got[0] != 3 {
+		t.Errorf("Positions() = %v, want [3]", got)
+	}
+	if b.Written() != written || written.CountOnes() != 3 {
+		t.Errorf("Written() has %d positions, want 3", b.Written().CountOnes())
+	}
+	for i := range b.Len() {
+		if got, want := b.WasSet(i), i == 3 || i == 4 || i == 70; got != want {
+			t.Errorf("WasSet(%d) = %v, want %v", i, got, want)
+		}
+	}
+}
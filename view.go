@@ -0,0 +1,67 @@
+package bitvector
+
+// VectorView is a read-only view of a range of a bit vector.
+// It answers queries through the parent without copying any bits.
+type VectorView struct {
+	parent BitVector // the viewed bit vector.
+	start  int       // the first index of the range in parent.
+	end    int       // the end index (exclusive) of the range in parent.
+	base   int       // the count of 1s in parent before start.
+}
+
+// View returns a view of the bits in [start, end) of the bit vector,
+// or nil if the range is out of range.
+func (b BitVector) View(start, end int) *VectorView {
+	if start < 0 || end > b.size || start > end {
+		return nil
+	}
+	base, _ := b.Rank1(start)
+	return &VectorView{
+		parent: b,
+		start:  start,
+		end:    end,
+		base:   base,
+	}
+}
+
+// Len returns the size of the view.
+func (v VectorView) Len() int {
+	return v.end - v.start
+}
+
+// Get returns true or false, the value of the i-th bit in the view.
+func (v VectorView) Get(i int) (bool, error) {
+	if i < 0 || i >= v.Len() {
+		return false, ErrorOutOfRange
+	}
+	return v.parent.Get(v.start + i)
+}
+
+// Rank1 returns the count of 1s before the i-th bit in the view.
+func (v VectorView) Rank1(i int) (int, error) {
+	if i < 0 || i > v.Len() {
+		return 0, ErrorOutOfRange
+	}
+	rank, err := v.parent.Rank1(v.start + i)
+	if err != nil {
+		return 0, err
+	}
+	return rank - v.base, nil
+}
+
+// Select1 returns the index of the i-th 1 in the view. It returns
+// ErrorOutOfRange for a negative i and ErrorNotExist if there are not more
+// than i 1s in the view.
+func (v VectorView) Select1(i int) (int, error) {
+	if i < 0 {
+		return 0, ErrorOutOfRange
+	}
+	if ones, _ := v.Rank1(v.Len()); i >= ones {
+		return 0, ErrorNotExist
+	}
+	pos, err := v.parent.Select1(v.base + i)
+	if err != nil {
+		return 0, err
+	}
+	return pos - v.start, nil
+}
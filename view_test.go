@@ -0,0 +1,79 @@
+package bitvector
+
+import (
+	"testing"
+)
+
+func TestView(t *testing.T) {
+	s, bv := random(1000)
+	for _, r := range [][2]int{{0, 0}, {0, 1000}, {3, 70}, {64, 128}, {500, 999}} {
+		start, end := r[0], r[1]
+		view := bv.View(start, end)
+		slice, err := bv.Slice(start, end)
+		if view == nil || err != nil {
+			t.Fatalf("View(%d, %d) = %v, Slice() error = %v", start, end, view, err)
+		}
+		if view.Len() != slice.Len() {
+			t.Errorf("View(%d, %d).Len() = %d, want %d", start, end, view.Len(), slice.Len())
+		}
+
+		for i := 0; i <= view.Len(); i++ {
+			got, err := view.Rank1(i)
+			want, _ := slice.Rank1(i)
+			if err != nil || got != want {
+				t.Errorf("View(%d, %d).Rank1(%d) = %d, %v, want %d", start, end, i, got, err, want)
+			}
+			if i == view.Len() {
+				break
+			}
+			if got, _ := view.Get(i); got != (s[start+i] == '1') {
+				t.Errorf("View(%d, %d).Get(%d) = %v", start, end, i, got)
+			}
+		}
+
+		ones, _ := slice.Rank1(slice.Len())
+		for i := 0; i < ones; i++ {
+			got, err := view.Select1(i)
+			want, _ := slice.Select1(i)
+			if err != nil || got != want {
+				t.Errorf("View(%d, %d).Select1(%d) = %d, %v, want %d", start, end, i, got, err, want)
+			}
+		}
+		if _, err := view.Select1(ones); err != ErrorNotExist {
+			t.Errorf("View(%d, %d).Select1(%d) error = %v, want %v", start, end, ones, err, ErrorNotExist)
+		}
+		if got, err := view.Select1(-1); got != 0 || err != ErrorOutOfRange {
+			t.Errorf("View(%d, %d).Select1(-1) = %d, %v, want 0, %v", start, end, got, err, ErrorOutOfRange)
+		}
+		if got, err := view.Rank1(view.Len() + 1); got != 0 || err != ErrorOutOfRange {
+			t.Errorf("View(%d, %d).Rank1(%d) = %d, %v, want 0, %v", start, end, view.Len()+1, got, err, ErrorOutOfRange)
+		}
+	}
+
+	// The errors of an unbuilt parent come with a zero result.
+	var unbuilt BitVector
+	if got, err := unbuilt.View(0, 0).Rank1(0); got != 0 || err == nil {
+		t.Errorf("View of an unbuilt vector: Rank1(0) = %d, %v, want 0 and an error", got, err)
+	}
+
+	if bv.View(10, 5) != nil || bv.View(0, 1001) != nil {
+		t.Errorf("View() with an invalid range is not nil")
+	}
+}
+
+func TestViewIsIndependent(t *testing.T) {
+	b := NewBuilder(100)
+	b.Set1(10)
+	bv := b.Build()
+	view := bv.View(0, 50)
+
+	// Rebuilding the parent from a new builder must not affect the view.
+	b = NewBuilder(100)
+	*bv = *b.Build()
+	if got, _ := view.Get(10); !got {
+		t.Errorf("Get(10) = false after replacing the parent")
+	}
+	if got, _ := view.Rank1(50); got != 1 {
+		t.Errorf("Rank1(50) = %d after replacing the parent, want 1", got)
+	}
+}
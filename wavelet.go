@@ -0,0 +1,118 @@
+package bitvector
+
+// waveletLevels is the number of levels of WaveletMatrix, one per bit of a byte.
+const waveletLevels = 8
+
+// WaveletMatrix is a sequence of bytes answering Access, Rank and Select over
+// its symbols with a bit vector per bit of a byte. Level l holds the l-th
+// most significant bit of every symbol, in the order left by stably moving
+// the symbols with a 0 at the previous levels before those with a 1.
+type WaveletMatrix struct {
+	n      int
+	levels [waveletLevels]*BitVector
+	zeros  [waveletLevels]int // the count of 0s at each level.
+}
+
+// NewWaveletMatrix makes a WaveletMatrix of the bytes of data.
+func NewWaveletMatrix(data []byte) *WaveletMatrix {
+	w := &WaveletMatrix{n: len(data)}
+	cur := append([]byte(nil), data...)
+	next := make([]byte, len(data))
+	for l := range waveletLevels {
+		shift := uint(waveletLevels - 1 - l)
+		b := NewBuilderWithSelectIndex(len(cur))
+		zeros := 0
+		for i, c := range cur {
+			if c>>shift&1 == 1 {
+				b.Set1(i)
+			} else {
+				next[zeros] = c
+				zeros++
+			}
+		}
+		j := zeros
+		for _, c := range cur {
+			if c>>shift&1 == 1 {
+				next[j] = c
+				j++
+			}
+		}
+		w.levels[l], w.zeros[l] = b.Build(), zeros
+		cur, next = next, cur
+	}
+	return w
+}
+
+// Len returns the length of the sequence.
+func (w WaveletMatrix) Len() int {
+	return w.n
+}
+
+// Access returns the i-th symbol.
+func (w WaveletMatrix) Access(i int) (byte, error) {
+	if i < 0 || i >= w.n {
+		return 0, ErrorOutOfRange
+	}
+	var c byte
+	for l, b := range w.levels {
+		if x, _ := b.Get(i); x {
+			c |= 1 << uint(waveletLevels-1-l)
+			i, _ = b.Rank1(i)
+			i += w.zeros[l]
+		} else {
+			i, _ = b.Rank0(i)
+		}
+	}
+	return c, nil
+}
+
+// down returns where the positions s and e of a level move to at the next
+// level, following the bit of symbol c at level l.
+func (w WaveletMatrix) down(c byte, l, s, e int) (int, int) {
+	b := w.levels[l]
+	if c>>uint(waveletLevels-1-l)&1 == 1 {
+		s, _ = b.Rank1(s)
+		e, _ = b.Rank1(e)
+		return w.zeros[l] + s, w.zeros[l] + e
+	}
+	s, _ = b.Rank0(s)
+	e, _ = b.Rank0(e)
+	return s, e
+}
+
+// Rank returns the count of symbol c before the i-th symbol.
+func (w WaveletMatrix) Rank(c byte, i int) (int, error) {
+	if i < 0 || i > w.n {
+		return 0, ErrorOutOfRange
+	}
+	s, e := 0, i
+	for l := range waveletLevels {
+		s, e = w.down(c, l, s, e)
+	}
+	return e - s, nil
+}
+
+// Select returns the index of the i-th occurrence of symbol c. It returns
+// ErrorOutOfRange for a negative i and ErrorNotExist if there are not more
+// than i occurrences.
+func (w WaveletMatrix) Select(c byte, i int) (int, error) {
+	if i < 0 {
+		return 0, ErrorOutOfRange
+	}
+	s, e := 0, w.n
+	for l := range waveletLevels {
+		s, e = w.down(c, l, s, e)
+	}
+	if i >= e-s {
+		return 0, ErrorNotExist
+	}
+	pos := s + i
+	for l := waveletLevels - 1; l >= 0; l-- {
+		if c>>uint(waveletLevels-1-l)&1 == 1 {
+			pos, _ = w.levels[l].Select1(pos - w.zeros[l])
+		} else {
+			pos, _ = w.levels[l].Select0(pos)
+		}
+	}
+	return pos, nil
+}
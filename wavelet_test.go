@@ -0,0 +1,65 @@
+package bitvector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestWaveletMatrix(t *testing.T) {
+	for _, data := range [][]byte{
+		nil,
+		[]byte("abracadabra"),
+		{0, 255, 0, 255, 128},
+		randomBytes(5000, 256),
+		randomBytes(5000, 4),
+	} {
+		w := NewWaveletMatrix(data)
+		if w.Len() != len(data) {
+			t.Errorf("Len() = %d, want %d", w.Len(), len(data))
+		}
+		var counts [256]int
+		occurrences := make([][]int, 256)
+		for i, c := range data {
+			if got, err := w.Access(i); err != nil || got != c {
+				t.Fatalf("Access(%d) = %d, %v, want %d", i, got, err, c)
+			}
+			if got, err := w.Rank(c, i); err != nil || got != counts[c] {
+				t.Fatalf("Rank(%d, %d) = %d, %v, want %d", c, i, got, err, counts[c])
+			}
+			counts[c]++
+			occurrences[c] = append(occurrences[c], i)
+		}
+		for c := range 256 {
+			if got, _ := w.Rank(byte(c), len(data)); got != counts[c] {
+				t.Errorf("Rank(%d, %d) = %d, want %d", c, len(data), got, counts[c])
+			}
+			for i, want := range occurrences[c] {
+				if got, err := w.Select(byte(c), i); err != nil || got != want {
+					t.Fatalf("Select(%d, %d) = %d, %v, want %d", c, i, got, err, want)
+				}
+			}
+			if _, err := w.Select(byte(c), counts[c]); err != ErrorNotExist {
+				t.Errorf("Select(%d, %d) error = %v, want %v", c, counts[c], err, ErrorNotExist)
+			}
+		}
+	}
+
+	w := NewWaveletMatrix([]byte("abc"))
+	if _, err := w.Access(3); err != ErrorOutOfRange {
+		t.Errorf("Access(3) error = %v, want %v", err, ErrorOutOfRange)
+	}
+	if _, err := w.Rank('a', 4); err != ErrorOutOfRange {
+		t.Errorf("Rank('a', 4) error = %v, want %v", err, ErrorOutOfRange)
+	}
+	if _, err := w.Select('a', -1); err != ErrorOutOfRange {
+		t.Errorf("Select('a', -1) error = %v, want %v", err, ErrorOutOfRange)
+	}
+}
+
+func randomBytes(n, alphabet int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(rand.Intn(alphabet))
+	}
+	return data
+}
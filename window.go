@@ -0,0 +1,39 @@
+package bitvector
+
+import "math/bits"
+
+// RankWindow answers Rank1 at increasing positions, keeping the count of 1s
+// before the current word so that the next query only counts the words it advanced over.
+type RankWindow struct {
+	b    *BitVector
+	k    int // the current word.
+	base int // the count of 1s before the k-th word.
+}
+
+// NewRankWindow makes a new RankWindow over b starting at the first word.
+func NewRankWindow(b *BitVector) *RankWindow {
+	return &RankWindow{b: b}
+}
+
+// Rank1 returns the count of 1s before the i-th bit. It is fastest when i
+// does not decrease between calls, but any i in [0, Len()] is answered.
+func (w *RankWindow) Rank1(i int) (int, error) {
+	if !w.b.built() {
+		return 0, ErrorNotBuilt
+	}
+	if i < 0 || i > w.b.size {
+		return 0, ErrorOutOfRange
+	}
+	if w.b.constant {
+		return w.b.Rank1(i)
+	}
+	k := i / bitLength
+	if k < w.k || k-w.k > groupWords {
+		w.k = k
+		w.base, _ = w.b.Rank1(k * bitLength)
+	}
+	for ; w.k < k; w.k++ {
+		w.base += bits.OnesCount64(w.b.at(w.k))
+	}
+	return w.base + bits.OnesCount64(w.b.at(k) & ^(maskFF<<uint(i%bitLength))), nil
+}
@@ -0,0 +1,67 @@
+package bitvector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRankWindow(t *testing.T) {
+	for _, size := range []int{0, 1, 64, 1000, 5000} {
+		_, bv := random(size)
+		all := NewBuilder(size)
+		all.SetAll()
+		for _, bv := range []*BitVector{bv, all.Build()} {
+			w := NewRankWindow(bv)
+			check := func(i int) {
+				t.Helper()
+				got, err := w.Rank1(i)
+				want, _ := bv.Rank1(i)
+				if err != nil || got != want {
+					t.Fatalf("size %d: RankWindow.Rank1(%d) = %d, %v, want %d", size, i, got, err, want)
+				}
+			}
+			for i := 0; i <= size; i++ {
+				check(i)
+			}
+			// Jumps back and far ahead are answered too.
+			for range 100 {
+				check(rand.Intn(size + 1))
+			}
+			if _, err := w.Rank1(size + 1); err != ErrorOutOfRange {
+				t.Errorf("size %d: Rank1(%d) error = %v, want %v", size, size+1, err, ErrorOutOfRange)
+			}
+		}
+	}
+}
+
+func BenchmarkRankWindow(b *testing.B) {
+	s, _ := random(bigSize)
+	for _, build := range []func(*Builder) *BitVector{(*Builder).Build, (*Builder).BuildSIMDFriendly, (*Builder).BuildRank9} {
+		builder := NewBuilder(bigSize)
+		for i, c := range s {
+			builder.Set(i, c == '1')
+		}
+		bv := build(builder)
+		layout := "default"
+		if bv.grouped {
+			layout = "SIMDFriendly"
+		} else if bv.rank9 != nil {
+			layout = "rank9"
+		}
+		b.Run(layout+"/Rank1", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < bigSize; j += 7 {
+					bv.Rank1(j)
+				}
+			}
+		})
+		b.Run(layout+"/RankWindow", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				w := NewRankWindow(bv)
+				for j := 0; j < bigSize; j += 7 {
+					w.Rank1(j)
+				}
+			}
+		})
+	}
+}